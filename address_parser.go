@@ -0,0 +1,96 @@
+package binproto
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	addressHeaderLen = 2
+	// BroadcastAddress is the destination address accepted by all devices on the bus
+	BroadcastAddress = byte(0xFF)
+)
+
+// ErrAddressMismatch is reported when the frame is not addressed to this node or comes from unexpected device
+var ErrAddressMismatch = errors.New("frame address doesn't match")
+
+// AddressParser wraps the EncodeDecoder and adds two bytes header with destination and source addresses
+// to each message. Header is a part of the payload, so it is covered by the checksum of the wrapped parser
+// It is used on multi-drop buses like RS-485, where many devices share the same line
+type AddressParser struct {
+	parser      EncodeDecoder
+	address     byte
+	destination byte
+
+	lastDestination byte
+	lastSource      byte
+	buffer          []byte
+	frameBuffer     []byte
+}
+
+// NewAddressParser returns new AddressParser which uses given address as the source of encoded messages
+func NewAddressParser(parser EncodeDecoder, address byte) *AddressParser {
+	return &AddressParser{parser: parser, address: address, destination: BroadcastAddress}
+}
+
+// Address returns the address of this node
+func (a *AddressParser) Address() byte {
+	return a.address
+}
+
+// SetDestination sets the destination address of the next encoded messages
+func (a *AddressParser) SetDestination(destination byte) {
+	a.destination = destination
+}
+
+// Destination returns the destination address of the encoded messages
+func (a *AddressParser) Destination() byte {
+	return a.destination
+}
+
+// Encode prepends the address header to the source and encodes it with the wrapped parser
+func (a *AddressParser) Encode(src []byte) ([]byte, error) {
+	return a.parser.Encode(a.addHeader(src))
+}
+
+// EncodeFrame encodes the source like Encode and ends the result with the frame delimiter
+func (a *AddressParser) EncodeFrame(src []byte) ([]byte, error) {
+	return encodeFrame(a.parser, a.addHeader(src), &a.frameBuffer)
+}
+
+// Decode decodes the source with the wrapped parser and strips the address header from the result
+// Addresses of the decoded message are returned by LastDestination and LastSource
+// Messages addressed to other devices are decoded too, it is up to the caller to ignore them
+func (a *AddressParser) Decode(src []byte) ([]byte, error) {
+	decoded, err := a.parser.Decode(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(decoded) < addressHeaderLen {
+		return nil, fmt.Errorf("%w. Message has no address header", ErrMessageTooShort)
+	}
+	a.lastDestination = decoded[0]
+	a.lastSource = decoded[1]
+	return decoded[addressHeaderLen:], nil
+}
+
+// LastDestination returns the destination address of the last decoded message
+func (a *AddressParser) LastDestination() byte {
+	return a.lastDestination
+}
+
+// LastSource returns the source address of the last decoded message
+func (a *AddressParser) LastSource() byte {
+	return a.lastSource
+}
+
+// Unwrap returns the wrapped parser
+func (a *AddressParser) Unwrap() EncodeDecoder {
+	return a.parser
+}
+
+func (a *AddressParser) addHeader(src []byte) []byte {
+	a.buffer = append(a.buffer[:0], a.destination, a.address)
+	a.buffer = append(a.buffer, src...)
+	return a.buffer
+}
@@ -0,0 +1,75 @@
+package binproto
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestAddressParserEncodeDecodePositive(t *testing.T) {
+	//GIVEN
+	src := []byte{1, 1, 1, 0, 0, 1, 5, 12, 44}
+	master := NewAddressParser(NewProtocolParser(), 1)
+	master.SetDestination(7)
+	device := NewAddressParser(NewProtocolParser(), 7)
+	//WHEN
+	encoded, _ := master.Encode(src)
+	decoded, err := device.Decode(encoded)
+	//THEN
+	if err != nil {
+		t.Error("decoding failed: ", err)
+	}
+	if !bytes.Equal(decoded, src) {
+		t.Errorf("Decoded array %v does not equal to the source %v", decoded, src)
+	}
+	if device.LastDestination() != 7 || device.LastSource() != 1 {
+		t.Errorf("Addresses %v->%v are different than expected 1->7", device.LastSource(), device.LastDestination())
+	}
+}
+
+func TestAddressParserAddsHeaderBeforeChecksum(t *testing.T) {
+	//GIVEN
+	src := []byte("hello")
+	parser := NewAddressParser(NewProtocolParser(), 1)
+	parser.SetDestination(7)
+	//WHEN
+	encoded, _ := parser.Encode(src)
+	decoded, _ := NewProtocolParser().Decode(encoded)
+	//THEN
+	if !bytes.Equal(decoded, append([]byte{7, 1}, src...)) {
+		t.Errorf("Message %v does not start with the address header", decoded)
+	}
+}
+
+func TestAddressParserDecodeWithoutHeaderFails(t *testing.T) {
+	//GIVEN
+	encoded, _ := NewProtocolParser().Encode([]byte{7})
+	parser := NewAddressParser(NewProtocolParser(), 7)
+	//WHEN
+	_, err := parser.Decode(encoded)
+	//THEN
+	if !errors.Is(err, ErrMessageTooShort) {
+		t.Errorf("decoding message without header returned %v, expected ErrMessageTooShort", err)
+	}
+}
+
+func TestAddressParserEncodeFrameWithCachedParser(t *testing.T) {
+	//GIVEN
+	src := []byte("hello")
+	parser := NewAddressParser(NewCachedProtocolParser(), 1)
+	parser.SetDestination(7)
+	device := NewAddressParser(NewProtocolParser(), 7)
+	//WHEN
+	frame, err := parser.EncodeFrame(src)
+	//THEN
+	if err != nil {
+		t.Fatal("encoding frame failed: ", err)
+	}
+	if frame[len(frame)-1] != 0 {
+		t.Errorf("Frame %v is not ended with 0 sign", frame)
+	}
+	decoded, err := device.Decode(frame[:len(frame)-1])
+	if err != nil || !bytes.Equal(decoded, src) {
+		t.Errorf("Decoded array %v does not equal to the source %v, error: %v", decoded, src, err)
+	}
+}
@@ -0,0 +1,63 @@
+package binproto
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrBroadcastTransaction is returned when the transaction is sent to the broadcast address
+// Devices don't respond to broadcasts, so Broadcast must be used instead
+var ErrBroadcastTransaction = errors.New("broadcast address can't be used in transaction")
+
+// BusMaster runs transactions with the devices sharing the multi-drop bus, like RS-485
+// Each message carries destination and source address. Frames addressed to other devices
+// and responses from devices other than the requested one are ignored
+// BusMaster must not be used by many goroutines at the same time
+type BusMaster struct {
+	parser     *AddressParser
+	readWriter *ProtocolReadWriter
+}
+
+// NewBusMaster returns new BusMaster with given bus address, which wraps given parser with AddressParser
+// Retry policy, readDelay and readTimeout are used like in NewProtocolReadWriterWithPolicy
+func NewBusMaster(parser EncodeDecoder, address byte, retryPolicy RetryPolicy, readDelay, readTimeout time.Duration) *BusMaster {
+	addressParser := NewAddressParser(parser, address)
+	return &BusMaster{
+		parser:     addressParser,
+		readWriter: NewProtocolReadWriterWithPolicy(addressParser, retryPolicy, readDelay, readTimeout),
+	}
+}
+
+// Transact sends given payload to the device with given address and waits for its response
+// Failed transactions are retried according to the retry policy
+// Returned slice is valid until the next BusMaster call
+func (b *BusMaster) Transact(readWriter io.ReadWriter, address byte, payload []byte) ([]byte, error) {
+	return b.TransactContext(context.Background(), readWriter, address, payload)
+}
+
+// TransactContext works like Transact, but stops as soon as given context is done
+func (b *BusMaster) TransactContext(ctx context.Context, readWriter io.ReadWriter, address byte, payload []byte) ([]byte, error) {
+	if address == BroadcastAddress {
+		return nil, ErrBroadcastTransaction
+	}
+	b.parser.SetDestination(address)
+	return b.readWriter.TransactContext(ctx, readWriter, payload)
+}
+
+// Broadcast sends given payload to all devices on the bus. No response is expected
+func (b *BusMaster) Broadcast(writer io.Writer, payload []byte) error {
+	b.parser.SetDestination(BroadcastAddress)
+	return b.readWriter.WriteFrame(writer, payload)
+}
+
+// ReadWriter returns the underlying ProtocolReadWriter, e.g. to enable resync mode or read the counters
+func (b *BusMaster) ReadWriter() *ProtocolReadWriter {
+	return b.readWriter
+}
+
+// Close stops the helper goroutine of the underlying ProtocolReadWriter
+func (b *BusMaster) Close() error {
+	return b.readWriter.Close()
+}
@@ -0,0 +1,133 @@
+package binproto
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const masterAddress = byte(1)
+
+// encodeAddressedFrames encodes the payloads sent from source to destination address as zero ended frames
+func encodeAddressedFrames(source, destination byte, payloads ...[]byte) []byte {
+	parser := NewAddressParser(NewProtocolParser(), source)
+	parser.SetDestination(destination)
+	var stream []byte
+	for _, payload := range payloads {
+		frame, _ := parser.EncodeFrame(payload)
+		stream = append(stream, frame...)
+	}
+	return stream
+}
+
+func TestBusMasterTransactShouldReturnResponseFromDevice(t *testing.T) {
+	// GIVEN
+	readWriterMock := &DeviceReadWriterMock{
+		responses: [][]byte{encodeAddressedFrames(7, masterAddress, []byte("world"))},
+	}
+	master := NewBusMaster(NewProtocolParser(), masterAddress, NewConstantBackoff(1, 0), 0, 1*time.Second)
+
+	// WHEN
+	response, err := master.Transact(readWriterMock, 7, []byte("hello"))
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("world"), response)
+}
+
+func TestBusMasterTransactShouldWrapCachedParser(t *testing.T) {
+	// GIVEN
+	readWriterMock := &DeviceReadWriterMock{
+		responses: [][]byte{encodeAddressedFrames(7, masterAddress, []byte("world"))},
+	}
+	master := NewBusMaster(NewCachedProtocolParser(), masterAddress, NewConstantBackoff(1, 0), 0, 1*time.Second)
+
+	// WHEN
+	response, err := master.Transact(readWriterMock, 7, []byte("hello"))
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("world"), response)
+}
+
+func TestBusMasterTransactShouldIgnoreStaleResponseOfWrappedSequenceParser(t *testing.T) {
+	// GIVEN
+	// sequence header is added by the wrapped parser, before the address header
+	staleFrame := encodeFrames(append([]byte{255, masterAddress, 7}, []byte("stale")...))
+	responseFrame := encodeFrames(append([]byte{0, masterAddress, 7}, []byte("world")...))
+	readWriterMock := &DeviceReadWriterMock{responses: [][]byte{append(staleFrame, responseFrame...)}}
+	master := NewBusMaster(NewSequenceParser(NewProtocolParser()), masterAddress, NewConstantBackoff(1, 0), 0, 1*time.Second)
+
+	// WHEN
+	response, err := master.Transact(readWriterMock, 7, []byte("hello"))
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("world"), response)
+	assert.Equal(t, uint64(1), master.ReadWriter().StaleResponses())
+}
+
+func TestBusMasterTransactShouldIgnoreFramesAddressedElsewhere(t *testing.T) {
+	// GIVEN
+	var stream []byte
+	stream = append(stream, encodeAddressedFrames(masterAddress, 5, []byte("to other device"))...)
+	stream = append(stream, encodeAddressedFrames(5, masterAddress, []byte("from other device"))...)
+	stream = append(stream, encodeAddressedFrames(7, masterAddress, []byte("world"))...)
+	readWriterMock := &DeviceReadWriterMock{responses: [][]byte{stream}}
+	master := NewBusMaster(NewProtocolParser(), masterAddress, NewConstantBackoff(1, 0), 0, 1*time.Second)
+
+	// WHEN
+	response, err := master.Transact(readWriterMock, 7, []byte("hello"))
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("world"), response)
+	assert.Equal(t, uint64(2), master.ReadWriter().IgnoredFrames())
+}
+
+func TestBusMasterTransactShouldReportAddressMismatch(t *testing.T) {
+	// GIVEN
+	readWriterMock := &DeviceReadWriterMock{
+		responses: [][]byte{encodeAddressedFrames(5, masterAddress, []byte("world"))},
+	}
+	master := NewBusMaster(NewProtocolParser(), masterAddress, NewConstantBackoff(1, 0), 0, 50*time.Millisecond)
+
+	// WHEN
+	_, err := master.Transact(readWriterMock, 7, []byte("hello"))
+
+	// THEN
+	assert.ErrorIs(t, err, ErrAddressMismatch)
+	assert.Equal(t, uint64(1), master.ReadWriter().IgnoredFrames())
+}
+
+func TestBusMasterBroadcastShouldWriteFrameToAllDevices(t *testing.T) {
+	// GIVEN
+	var output bytes.Buffer
+	master := NewBusMaster(NewProtocolParser(), masterAddress, NewConstantBackoff(1, 0), 0, 1*time.Second)
+	device := NewAddressParser(NewProtocolParser(), 7)
+
+	// WHEN
+	err := master.Broadcast(&output, []byte("sync"))
+	frame := output.Bytes()
+	payload, decodeErr := device.Decode(frame[:len(frame)-1])
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Nil(t, decodeErr)
+	assert.Equal(t, []byte("sync"), payload)
+	assert.Equal(t, BroadcastAddress, device.LastDestination())
+	assert.Equal(t, masterAddress, device.LastSource())
+}
+
+func TestBusMasterTransactShouldRejectBroadcastAddress(t *testing.T) {
+	// GIVEN
+	master := NewBusMaster(NewProtocolParser(), masterAddress, NewConstantBackoff(1, 0), 0, 1*time.Second)
+
+	// WHEN
+	_, err := master.Transact(&DeviceReadWriterMock{}, BroadcastAddress, []byte("hello"))
+
+	// THEN
+	assert.Equal(t, ErrBroadcastTransaction, err)
+}
@@ -1,57 +1,122 @@
 package binproto
 
-import "unsafe"
-
 // CachedProtocolParser is a wrapper around binproto which adds simple memory cache
 // Each message will be stored in cache on first encode or decode
 // If given message was parsed previously, its cached results will be returned immediately
+// Encoded and decoded messages are stored in separate caches, each can be enabled independently
+// Cache can be limited by number of entries and bytes, least recently used entries are evicted first
 type CachedProtocolParser struct {
-	protocol *ProtocolParser
-	cache    map[string][]byte
+	protocol    *ProtocolParser
+	encodeCache *lruCache
+	decodeCache *lruCache
+	last        []byte
+}
+
+// CacheConfig describes single cache of the CachedProtocolParser
+// Zero value of any limit means that the cache is not limited by it
+type CacheConfig struct {
+	Enabled    bool
+	MaxEntries int
+	MaxBytes   int
 }
 
-// NewCachedProtocolParser returns new cached protocol object
+// NewCachedProtocolParser returns new cached protocol object with unlimited encode and decode caches
 func NewCachedProtocolParser() *CachedProtocolParser {
-	cache := make(map[string][]byte)
-	return &CachedProtocolParser{protocol: NewProtocolParser(), cache: cache}
+	return NewCachedProtocolParserWithLimits(0, 0)
+}
+
+// NewCachedProtocolParserWithLimits returns new cached protocol object
+// Both encode and decode caches store at most maxEntries entries using at most maxBytes bytes for keys and values
+// Zero value of any limit means that the cache is not limited by it
+func NewCachedProtocolParserWithLimits(maxEntries, maxBytes int) *CachedProtocolParser {
+	config := CacheConfig{Enabled: true, MaxEntries: maxEntries, MaxBytes: maxBytes}
+	return NewCachedProtocolParserWithConfig(config, config)
+}
+
+// NewCachedProtocolParserWithConfig returns new cached protocol object with separately configured caches
+// If given cache is not enabled, related operation is always passed to the protocol parser
+func NewCachedProtocolParserWithConfig(encodeCache, decodeCache CacheConfig) *CachedProtocolParser {
+	return &CachedProtocolParser{protocol: NewProtocolParser(),
+		encodeCache: newLruCacheFromConfig(encodeCache), decodeCache: newLruCacheFromConfig(decodeCache)}
 }
 
 // Encode encodes given source slice with COBS encoding and adds checksum
 // Encoded data will be stored in memory.
 // If given source have been encoded previously its encoded version will be obtained from memory
+// Returned slice is shared with the cache, so it must not be modified
 func (c *CachedProtocolParser) Encode(src []byte) ([]byte, error) {
-	srcHash := *(*string)(unsafe.Pointer(&src))
-	if encoded, ok := c.cache[srcHash]; ok {
-		return encoded, nil
-	}
-	data, err := c.protocol.Encode(src)
-	if err != nil {
-		return nil, err
-	}
-	// copy src to make the cache immune to future src changes
-	c.cache[string(src)] = data
-	return data, nil
+	return c.parse(c.encodeCache, c.protocol.Encode, src)
 }
 
 // Decode decodes given source slice, which was previously encoded with COBS encoding
 // Decoded data will be stored in memory.
 // If given source have been decoded previously its decoded version will be obtained from memory
+// Returned slice is shared with the cache, so it must not be modified
 func (c *CachedProtocolParser) Decode(src []byte) ([]byte, error) {
-	srcHash := *(*string)(unsafe.Pointer(&src))
-	if decoded, ok := c.cache[srcHash]; ok {
-		return decoded, nil
-	}
-	data, err := c.protocol.Decode(src)
-	if err != nil {
-		return nil, err
-	}
-	// copy src to make the cache immune to future src changes
-	c.cache[string(src)] = data
-	return data, nil
+	return c.parse(c.decodeCache, c.protocol.Decode, src)
 }
 
 // Copy will make a copy of the last encode/decode operation
 // ! This function will allocate a new buffer for each call, so use it wisely
 func (c *CachedProtocolParser) Copy() []byte {
-	return c.protocol.Copy()
+	newArray := make([]byte, len(c.last))
+	copy(newArray, c.last)
+	return newArray
+}
+
+// Stats returns combined counters of encode and decode caches
+func (c *CachedProtocolParser) Stats() CacheStats {
+	encodeStats, decodeStats := c.EncodeStats(), c.DecodeStats()
+	return CacheStats{
+		Hits:      encodeStats.Hits + decodeStats.Hits,
+		Misses:    encodeStats.Misses + decodeStats.Misses,
+		Evictions: encodeStats.Evictions + decodeStats.Evictions,
+		Entries:   encodeStats.Entries + decodeStats.Entries,
+		Bytes:     encodeStats.Bytes + decodeStats.Bytes,
+	}
+}
+
+// EncodeStats returns encode cache hit, miss and eviction counters along with current cache usage
+func (c *CachedProtocolParser) EncodeStats() CacheStats {
+	return c.encodeCache.getStats()
+}
+
+// DecodeStats returns decode cache hit, miss and eviction counters along with current cache usage
+func (c *CachedProtocolParser) DecodeStats() CacheStats {
+	return c.decodeCache.getStats()
+}
+
+// Invalidate removes cached encode and decode results for given source
+// Returns true if the source was found in any cache
+func (c *CachedProtocolParser) Invalidate(src []byte) bool {
+	encodeRemoved := c.encodeCache.remove(src)
+	decodeRemoved := c.decodeCache.remove(src)
+	return encodeRemoved || decodeRemoved
+}
+
+// Purge removes all cached results, usage counters are preserved
+func (c *CachedProtocolParser) Purge() {
+	c.encodeCache.purge()
+	c.decodeCache.purge()
+}
+
+func (c *CachedProtocolParser) parse(cache *lruCache, parseFunc func([]byte) ([]byte, error), src []byte) ([]byte, error) {
+	if result, ok := cache.get(src); ok {
+		c.last = result
+		return result, nil
+	}
+	data, err := parseFunc(src)
+	if err != nil {
+		return nil, err
+	}
+	if cache == nil {
+		c.last = data
+		return data, nil
+	}
+	// copy data, as the protocol parser overwrites its buffer on each operation
+	result := make([]byte, len(data))
+	copy(result, data)
+	cache.add(src, result)
+	c.last = result
+	return result, nil
 }
@@ -23,6 +23,157 @@ func TestCacheEncodeDecodePositive(t *testing.T) {
 	}
 }
 
+func TestCacheStatsCountHitsAndMisses(t *testing.T) {
+	//GIVEN
+	src := []byte{1, 1, 1, 0, 0, 1, 5, 12, 44}
+	cache := NewCachedProtocolParser()
+	//WHEN
+	cache.Encode(src)
+	cache.Encode(src)
+	cache.Encode(src)
+	stats := cache.Stats()
+	//THEN
+	if stats.Hits != 2 || stats.Misses != 1 || stats.Entries != 1 {
+		t.Errorf("Cache stats %+v are different than expected", stats)
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsedEntry(t *testing.T) {
+	//GIVEN
+	first := []byte{1}
+	second := []byte{2}
+	third := []byte{3}
+	cache := NewCachedProtocolParserWithLimits(2, 0)
+	//WHEN
+	cache.Encode(first)
+	cache.Encode(second)
+	cache.Encode(first)
+	cache.Encode(third)
+	stats := cache.Stats()
+	cache.Encode(first)
+	cache.Encode(second)
+	//THEN
+	if stats.Evictions != 1 || stats.Entries != 2 {
+		t.Errorf("Cache stats %+v are different than expected", stats)
+	}
+	if cache.Stats().Hits != 2 {
+		t.Errorf("Least recently used entry was not evicted. Stats: %+v", cache.Stats())
+	}
+}
+
+func TestCacheRespectsByteBudget(t *testing.T) {
+	//GIVEN
+	maxBytes := 64
+	cache := NewCachedProtocolParserWithLimits(0, maxBytes)
+	//WHEN
+	for i := 0; i < 20; i++ {
+		cache.Encode([]byte{byte(i), 1, 2, 3})
+	}
+	stats := cache.Stats()
+	//THEN
+	if stats.Bytes > maxBytes {
+		t.Errorf("Cache uses %v bytes, which exceeds the budget of %v", stats.Bytes, maxBytes)
+	}
+	if stats.Evictions == 0 {
+		t.Errorf("Cache didn't evict any entry. Stats: %+v", stats)
+	}
+}
+
+func TestCacheSkipsEntriesBiggerThanByteBudget(t *testing.T) {
+	//GIVEN
+	cache := NewCachedProtocolParserWithLimits(0, 4)
+	//WHEN
+	cache.Encode([]byte{1, 2, 3, 4, 5})
+	//THEN
+	if cache.Stats().Entries != 0 {
+		t.Errorf("Entry bigger than byte budget was stored. Stats: %+v", cache.Stats())
+	}
+}
+
+func TestCacheInvalidateAndPurge(t *testing.T) {
+	//GIVEN
+	first := []byte{1}
+	second := []byte{2}
+	cache := NewCachedProtocolParser()
+	cache.Encode(first)
+	cache.Encode(second)
+	//WHEN
+	invalidated := cache.Invalidate(first)
+	invalidatedAgain := cache.Invalidate(first)
+	entriesAfterInvalidate := cache.Stats().Entries
+	cache.Purge()
+	//THEN
+	if !invalidated || invalidatedAgain {
+		t.Errorf("Invalidate returned %v and %v, expected true and false", invalidated, invalidatedAgain)
+	}
+	if entriesAfterInvalidate != 1 {
+		t.Errorf("Cache contains %v entries after invalidate, expected 1", entriesAfterInvalidate)
+	}
+	if stats := cache.Stats(); stats.Entries != 0 || stats.Bytes != 0 {
+		t.Errorf("Cache is not empty after purge. Stats: %+v", stats)
+	}
+}
+
+func TestCacheSeparatesEncodeAndDecodeResults(t *testing.T) {
+	//GIVEN
+	src := []byte{1, 1, 1, 0, 0, 1, 5, 12, 44}
+	proto := NewProtocolParser()
+	encoded, _ := proto.Encode(src)
+	encodedSave := make([]byte, len(encoded))
+	copy(encodedSave, encoded)
+	reencoded, _ := proto.Encode(encodedSave)
+	expectedReencoded := make([]byte, len(reencoded))
+	copy(expectedReencoded, reencoded)
+	cache := NewCachedProtocolParser()
+	//WHEN
+	cache.Decode(encodedSave)
+	result, _ := cache.Encode(encodedSave)
+	//THEN
+	if !bytes.Equal(result, expectedReencoded) {
+		t.Errorf("Encoded array %v does not equal to the expected %v", result, expectedReencoded)
+	}
+}
+
+func TestCacheResultsAreNotOverwrittenByNextOperation(t *testing.T) {
+	//GIVEN
+	cache := NewCachedProtocolParser()
+	first, _ := cache.Encode([]byte("hello"))
+	firstSave := make([]byte, len(first))
+	copy(firstSave, first)
+	//WHEN
+	cache.Encode([]byte("world"))
+	cached, _ := cache.Encode([]byte("hello"))
+	//THEN
+	if !bytes.Equal(first, firstSave) {
+		t.Errorf("Encoded array %v was overwritten, expected %v", first, firstSave)
+	}
+	if !bytes.Equal(cached, firstSave) {
+		t.Errorf("Cached array %v does not equal to the expected %v", cached, firstSave)
+	}
+}
+
+func TestCacheWithDecodeCacheOnly(t *testing.T) {
+	//GIVEN
+	src := []byte{1, 1, 1, 0, 0, 1, 5, 12, 44}
+	cache := NewCachedProtocolParserWithConfig(CacheConfig{}, CacheConfig{Enabled: true})
+	//WHEN
+	cache.Encode(src)
+	encoded := cache.Copy()
+	cache.Encode(src)
+	cache.Decode(encoded)
+	decoded, _ := cache.Decode(encoded)
+	//THEN
+	if !bytes.Equal(decoded, src) {
+		t.Errorf("Decoded array %v does not equal to the source %v", decoded, src)
+	}
+	if stats := cache.EncodeStats(); stats != (CacheStats{}) {
+		t.Errorf("Disabled encode cache was used. Stats: %+v", stats)
+	}
+	if stats := cache.DecodeStats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("Decode cache stats %+v are different than expected", stats)
+	}
+}
+
 func BenchmarkCache_Encode(b *testing.B) {
 	src := []byte{1, 1, 1, 0, 0, 1, 5, 12, 44}
 	cache := NewCachedProtocolParser()
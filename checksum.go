@@ -0,0 +1,11 @@
+package binproto
+
+// Checksum calculates check sum over the message data
+// Implementations must not keep any state between calls, so single instance can be shared
+type Checksum interface {
+	// Size returns the number of bytes written by Compute
+	Size() int
+	// Compute calculates check sum over src and writes it to the dest
+	// Dest slice must be at least Size() bytes long
+	Compute(src []byte, dest []byte)
+}
@@ -12,7 +12,7 @@ func cobsEncode(src []byte, dest []byte) (int, error) {
 
 	requiredLen := cobsGetEncodedBufferSize(srcLen)
 	if len(dest) < requiredLen {
-		return 0, fmt.Errorf("destination array length is too small. Required: %v, get: %v", requiredLen, len(dest))
+		return 0, fmt.Errorf("%w. Required: %v, get: %v", ErrBufferTooSmall, requiredLen, len(dest))
 	}
 
 	codePtr := 0
@@ -57,13 +57,13 @@ func cobsDecode(enc []byte, dest []byte) (int, error) {
 	for ptr < encLen {
 		code := enc[ptr]
 
-		if ptr+int(code) > encLen {
-			return 0, fmt.Errorf("encoded message is too short. Required: %v, get: %v", ptr+int(code), encLen)
+		if code == 0 || ptr+int(code) > encLen {
+			return 0, &FramingError{Offset: ptr, Code: code, Length: encLen}
 		}
 		ptr++
 
 		if pos+int(code) > destLen {
-			return 0, fmt.Errorf("destination array length is too short. Required: %v, get: %v", pos+int(code), destLen)
+			return 0, fmt.Errorf("%w. Required: %v, get: %v", ErrBufferTooSmall, pos+int(code), destLen)
 		}
 
 		for i := 1; i < int(code); i++ {
@@ -80,6 +80,52 @@ func cobsDecode(enc []byte, dest []byte) (int, error) {
 	return pos - 1, nil // trim phantom zero
 }
 
+// cobsDecodeSplit works like cobsDecode, but the decoded data which doesn't fit into dest is written to the tail
+func cobsDecodeSplit(enc []byte, dest []byte, tail []byte) (int, error) {
+	encLen := len(enc)
+	destLen := len(dest) + len(tail)
+	ptr := 0
+	pos := 0
+
+	if encLen == 0 {
+		return 0, nil
+	}
+
+	for ptr < encLen {
+		code := enc[ptr]
+
+		if code == 0 || ptr+int(code) > encLen {
+			return 0, &FramingError{Offset: ptr, Code: code, Length: encLen}
+		}
+		ptr++
+
+		if pos+int(code) > destLen {
+			return 0, fmt.Errorf("%w. Required: %v, get: %v", ErrBufferTooSmall, pos+int(code), destLen)
+		}
+
+		block := enc[ptr : ptr+int(code)-1]
+		written := 0
+		if pos < len(dest) {
+			written = copy(dest[pos:], block)
+		}
+		if written < len(block) {
+			copy(tail[pos+written-len(dest):], block[written:])
+		}
+		pos += len(block)
+		ptr += len(block)
+		if code < 0xFF {
+			if pos < len(dest) {
+				dest[pos] = 0
+			} else {
+				tail[pos-len(dest)] = 0
+			}
+			pos++
+		}
+	}
+
+	return pos - 1, nil // trim phantom zero
+}
+
 func cobsGetEncodedBufferSize(rawSize int) int {
 	return rawSize + rawSize/254 + 1
 }
@@ -2,6 +2,7 @@ package binproto
 
 import (
 	"bytes"
+	"errors"
 	"testing"
 )
 
@@ -75,6 +76,57 @@ func TestCobsDecodeWithTooSmallDest(t *testing.T) {
 	}
 }
 
+func TestCobsDecodeWithMalformedCodeReturnsFramingError(t *testing.T) {
+	//GIVEN
+	encoded := []byte{2, 1, 5, 1}
+	decodeBuffer := make([]byte, 100)
+	//WHEN
+	_, err := cobsDecode(encoded, decodeBuffer)
+	//THEN
+	var framingErr *FramingError
+	if !errors.As(err, &framingErr) {
+		t.Fatalf("decoding malformed message returned %v, expected FramingError", err)
+	}
+	if framingErr.Offset != 2 || framingErr.Code != 5 || framingErr.Length != len(encoded) {
+		t.Errorf("framing error %+v has unexpected fields", framingErr)
+	}
+	if !errors.Is(err, ErrFraming) {
+		t.Error("framing error does not match ErrFraming")
+	}
+}
+
+func TestCobsDecodeWithZeroInsideFrameReturnsFramingError(t *testing.T) {
+	//GIVEN
+	encoded := []byte{2, 1, 0, 1}
+	decodeBuffer := make([]byte, 100)
+	//WHEN
+	_, err := cobsDecode(encoded, decodeBuffer)
+	//THEN
+	var framingErr *FramingError
+	if !errors.As(err, &framingErr) {
+		t.Fatalf("decoding message with 0 sign returned %v, expected FramingError", err)
+	}
+	if framingErr.Offset != 2 || framingErr.Code != 0 {
+		t.Errorf("framing error %+v has unexpected fields", framingErr)
+	}
+}
+
+func TestCobsEncodeDecodeWithTooSmallDestMatchesErrBufferTooSmall(t *testing.T) {
+	//GIVEN
+	src := []byte{1, 1, 1, 0, 0, 5, 0}
+	encoded := []byte{4, 1, 1, 1, 1, 2, 5, 1}
+	//WHEN
+	_, encodeErr := cobsEncode(src, make([]byte, 2))
+	_, decodeErr := cobsDecode(encoded, make([]byte, 2))
+	//THEN
+	if !errors.Is(encodeErr, ErrBufferTooSmall) {
+		t.Errorf("encoding error %v does not match ErrBufferTooSmall", encodeErr)
+	}
+	if !errors.Is(decodeErr, ErrBufferTooSmall) {
+		t.Errorf("decoding error %v does not match ErrBufferTooSmall", decodeErr)
+	}
+}
+
 func BenchmarkCobsEncode(b *testing.B) {
 	src := []byte{1, 1, 1, 0, 0, 5, 0}
 	encodeBuffer := make([]byte, 100)
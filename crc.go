@@ -0,0 +1,165 @@
+package binproto
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+)
+
+const (
+	crc8Len  = 1
+	crc16Len = 2
+	crc32Len = 4
+)
+
+var (
+	crc8Table        = makeCrc8Table(0x07)
+	crc16CCITTTable  = makeCrc16Table(0x1021)
+	crc16ModbusTable = makeCrc16ReflectedTable(0xA001)
+	crc32IEEETable   = crc32.MakeTable(crc32.IEEE)
+	crc32CTable      = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// CRC8 implements CRC-8 checksum (polynomial 0x07, init 0x00)
+type CRC8 struct{}
+
+// NewCRC8 returns new CRC-8 checksum
+func NewCRC8() *CRC8 {
+	return &CRC8{}
+}
+
+// Size returns the checksum length in bytes
+func (c *CRC8) Size() int {
+	return crc8Len
+}
+
+// Compute calculates CRC-8 checksum over src and writes it to the dest
+func (c *CRC8) Compute(src []byte, dest []byte) {
+	crc := byte(0)
+	for _, val := range src {
+		crc = crc8Table[crc^val]
+	}
+	dest[0] = crc
+}
+
+// CRC16 implements 16 bit cyclic redundancy check
+// Use NewCRC16CCITT or NewCRC16Modbus to get one of the supported variants
+type CRC16 struct {
+	table        *[256]uint16
+	init         uint16
+	reflected    bool
+	littleEndian bool
+}
+
+// NewCRC16CCITT returns new CRC-16/CCITT-FALSE checksum (polynomial 0x1021, init 0xFFFF)
+// Result is written in big endian order
+func NewCRC16CCITT() *CRC16 {
+	return &CRC16{table: crc16CCITTTable, init: 0xFFFF}
+}
+
+// NewCRC16Modbus returns new CRC-16/MODBUS checksum (reflected polynomial 0x8005, init 0xFFFF)
+// Result is written in little endian order, as it is transmitted in Modbus RTU frames
+func NewCRC16Modbus() *CRC16 {
+	return &CRC16{table: crc16ModbusTable, init: 0xFFFF, reflected: true, littleEndian: true}
+}
+
+// Size returns the checksum length in bytes
+func (c *CRC16) Size() int {
+	return crc16Len
+}
+
+// Compute calculates CRC-16 checksum over src and writes it to the dest
+func (c *CRC16) Compute(src []byte, dest []byte) {
+	crc := c.init
+	if c.reflected {
+		for _, val := range src {
+			crc = (crc >> 8) ^ c.table[byte(crc)^val]
+		}
+	} else {
+		for _, val := range src {
+			crc = (crc << 8) ^ c.table[byte(crc>>8)^val]
+		}
+	}
+
+	if c.littleEndian {
+		dest[0] = byte(crc)
+		dest[1] = byte(crc >> 8)
+		return
+	}
+	dest[0] = byte(crc >> 8)
+	dest[1] = byte(crc)
+}
+
+// CRC32 implements 32 bit cyclic redundancy check
+// Use NewCRC32 or NewCRC32C to get one of the supported variants
+// Result is written in big endian order
+type CRC32 struct {
+	table *crc32.Table
+}
+
+// NewCRC32 returns new CRC-32 (IEEE) checksum
+func NewCRC32() *CRC32 {
+	return &CRC32{table: crc32IEEETable}
+}
+
+// NewCRC32C returns new CRC-32C (Castagnoli) checksum
+func NewCRC32C() *CRC32 {
+	return &CRC32{table: crc32CTable}
+}
+
+// Size returns the checksum length in bytes
+func (c *CRC32) Size() int {
+	return crc32Len
+}
+
+// Compute calculates CRC-32 checksum over src and writes it to the dest
+func (c *CRC32) Compute(src []byte, dest []byte) {
+	binary.BigEndian.PutUint32(dest, crc32.Checksum(src, c.table))
+}
+
+func makeCrc8Table(poly byte) *[256]byte {
+	table := new([256]byte)
+	for i := range table {
+		crc := byte(i)
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ poly
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}
+
+func makeCrc16Table(poly uint16) *[256]uint16 {
+	table := new([256]uint16)
+	for i := range table {
+		crc := uint16(i) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ poly
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}
+
+func makeCrc16ReflectedTable(poly uint16) *[256]uint16 {
+	table := new([256]uint16)
+	for i := range table {
+		crc := uint16(i)
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x0001 != 0 {
+				crc = crc>>1 ^ poly
+			} else {
+				crc >>= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}
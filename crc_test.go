@@ -0,0 +1,51 @@
+package binproto
+
+import (
+	"bytes"
+	"testing"
+)
+
+// all vectors are calculated over the standard "123456789" check input
+var crcCheckInput = []byte("123456789")
+
+func TestCrcChecksums(t *testing.T) {
+	//GIVEN
+	vectors := []struct {
+		name        string
+		checksum    Checksum
+		expectedCrc []byte
+	}{
+		{"CRC-8", NewCRC8(), []byte{0xF4}},
+		{"CRC-16/CCITT-FALSE", NewCRC16CCITT(), []byte{0x29, 0xB1}},
+		{"CRC-16/MODBUS", NewCRC16Modbus(), []byte{0x37, 0x4B}},
+		{"CRC-32", NewCRC32(), []byte{0xCB, 0xF4, 0x39, 0x26}},
+		{"CRC-32C", NewCRC32C(), []byte{0xE3, 0x06, 0x92, 0x83}},
+	}
+	for _, vector := range vectors {
+		crc := make([]byte, vector.checksum.Size())
+		//WHEN
+		vector.checksum.Compute(crcCheckInput, crc)
+		//THEN
+		if !bytes.Equal(crc, vector.expectedCrc) {
+			t.Errorf("%v value %X is not equal to the expected one %X", vector.name, crc, vector.expectedCrc)
+		}
+	}
+}
+
+func BenchmarkCRC16CCITT(b *testing.B) {
+	src := []byte{1, 2, 3, 4, 5, 6}
+	checksum := NewCRC16CCITT()
+	crc := make([]byte, checksum.Size())
+	for i := 0; i < b.N; i++ {
+		checksum.Compute(src, crc)
+	}
+}
+
+func BenchmarkCRC32C(b *testing.B) {
+	src := []byte{1, 2, 3, 4, 5, 6}
+	checksum := NewCRC32C()
+	crc := make([]byte, checksum.Size())
+	for i := 0; i < b.N; i++ {
+		checksum.Compute(src, crc)
+	}
+}
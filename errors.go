@@ -0,0 +1,102 @@
+package binproto
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrMessageTooShort is returned when decoded message is too short to contain the checksum
+	ErrMessageTooShort = errors.New("decoded message is too short")
+	// ErrBufferTooSmall is returned when destination buffer can't hold the result
+	ErrBufferTooSmall = errors.New("destination array length is too small")
+	// ErrFraming is matched by every FramingError
+	ErrFraming = errors.New("malformed COBS frame")
+	// ErrChecksumMismatch is matched by every ChecksumError
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+)
+
+// FramingError is returned when encoded message is not a valid COBS frame
+// Use errors.As to inspect the offset and the value of the invalid code byte
+type FramingError struct {
+	// Offset is the position of the invalid code byte in the encoded message
+	Offset int
+	// Code is the value of the invalid code byte
+	Code byte
+	// Length is the length of the encoded message
+	Length int
+}
+
+func (e *FramingError) Error() string {
+	if e.Code == 0 {
+		return fmt.Sprintf("encoded message contains 0 sign at offset %v", e.Offset)
+	}
+	return fmt.Sprintf("encoded message is too short. Required: %v, get: %v", e.Offset+int(e.Code), e.Length)
+}
+
+// Is reports whether target is ErrFraming
+func (e *FramingError) Is(target error) bool {
+	return target == ErrFraming
+}
+
+// ChecksumError is returned when checksum calculated over decoded message doesn't match the received one
+// Use errors.As to inspect both values
+type ChecksumError struct {
+	// Expected is the checksum calculated over the decoded message
+	Expected []byte
+	// Actual is the checksum received with the message
+	Actual []byte
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("calculated crc %v doesn't match received one %v", e.Expected, e.Actual)
+}
+
+// Is reports whether target is ErrChecksumMismatch
+func (e *ChecksumError) Is(target error) bool {
+	return target == ErrChecksumMismatch
+}
+
+// Attempt describes single failed attempt of the retried operation
+type Attempt struct {
+	Err      error
+	Start    time.Time
+	Duration time.Duration
+}
+
+// RetryError is returned when all attempts of the retried operation failed
+// It unwraps to the errors of all attempts, so errors.Is and errors.As match any of them
+type RetryError struct {
+	Attempts []Attempt
+}
+
+func (e *RetryError) Error() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%v attempts failed:", len(e.Attempts))
+	for i, attempt := range e.Attempts {
+		if i > 0 {
+			builder.WriteString(";")
+		}
+		fmt.Fprintf(&builder, " [%v] %v (%v)", i+1, attempt.Err, attempt.Duration)
+	}
+	return builder.String()
+}
+
+// Unwrap returns errors of all attempts
+func (e *RetryError) Unwrap() []error {
+	errs := make([]error, len(e.Attempts))
+	for i, attempt := range e.Attempts {
+		errs[i] = attempt.Err
+	}
+	return errs
+}
+
+// Last returns the error of the last attempt
+func (e *RetryError) Last() error {
+	if len(e.Attempts) == 0 {
+		return nil
+	}
+	return e.Attempts[len(e.Attempts)-1].Err
+}
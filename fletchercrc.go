@@ -1,12 +1,13 @@
 package binproto
 
-var buff = make([]byte, 2)
+import "encoding/binary"
 
 const (
-	crcLen = 2
+	fletcher16Len = 2
+	fletcher32Len = 4
 )
 
-func fletcher16(src []byte) []byte {
+func fletcher16(src []byte, dest []byte) {
 	sumA, sumB := uint16(0), uint16(0)
 
 	for _, val := range src {
@@ -14,7 +15,57 @@ func fletcher16(src []byte) []byte {
 		sumB = (sumB + sumA) % 255
 	}
 
-	buff[0] = byte(sumA)
-	buff[1] = byte(sumB)
-	return buff
+	dest[0] = byte(sumA)
+	dest[1] = byte(sumB)
+}
+
+// Fletcher16 implements Fletcher-16 checksum
+// The first written byte is the simple sum, the second one is the sum of sums
+type Fletcher16 struct{}
+
+// NewFletcher16 returns new Fletcher-16 checksum
+func NewFletcher16() *Fletcher16 {
+	return &Fletcher16{}
+}
+
+// Size returns the checksum length in bytes
+func (f *Fletcher16) Size() int {
+	return fletcher16Len
+}
+
+// Compute calculates Fletcher-16 checksum over src and writes it to the dest
+func (f *Fletcher16) Compute(src []byte, dest []byte) {
+	fletcher16(src, dest)
+}
+
+// Fletcher32 implements Fletcher-32 checksum
+// Source data is processed as little endian 16 bit words, odd length data is padded with 0
+// Result is written in big endian order
+type Fletcher32 struct{}
+
+// NewFletcher32 returns new Fletcher-32 checksum
+func NewFletcher32() *Fletcher32 {
+	return &Fletcher32{}
+}
+
+// Size returns the checksum length in bytes
+func (f *Fletcher32) Size() int {
+	return fletcher32Len
+}
+
+// Compute calculates Fletcher-32 checksum over src and writes it to the dest
+func (f *Fletcher32) Compute(src []byte, dest []byte) {
+	sumA, sumB := uint32(0), uint32(0)
+	srcLen := len(src)
+
+	for i := 0; i < srcLen; i += 2 {
+		word := uint32(src[i])
+		if i+1 < srcLen {
+			word |= uint32(src[i+1]) << 8
+		}
+		sumA = (sumA + word) % 65535
+		sumB = (sumB + sumA) % 65535
+	}
+
+	binary.BigEndian.PutUint32(dest, sumB<<16|sumA)
 }
@@ -9,8 +9,9 @@ func TestFletcher16(t *testing.T) {
 	//GIVEN
 	src := []byte{1, 2, 3, 4, 5, 6}
 	expectedCrc := []byte{21, 56}
+	crc := make([]byte, fletcher16Len)
 	//WHEN
-	crc := fletcher16(src)
+	fletcher16(src, crc)
 	//THEN
 	if !bytes.Equal(crc, expectedCrc) {
 		t.Errorf("Crc value %v is not equal to the expected one %v", crc, expectedCrc)
@@ -19,7 +20,54 @@ func TestFletcher16(t *testing.T) {
 
 func BenchmarkFletcher16(b *testing.B) {
 	src := []byte{1, 2, 3, 4, 5, 6}
+	crc := make([]byte, fletcher16Len)
 	for i := 0; i < b.N; i++ {
-		fletcher16(src)
+		fletcher16(src, crc)
+	}
+}
+
+func TestFletcher16Checksum(t *testing.T) {
+	//GIVEN
+	vectors := map[string][]byte{
+		"abcde":  {0xF0, 0xC8},
+		"abcdef": {0x57, 0x20},
+	}
+	checksum := NewFletcher16()
+	for src, expectedCrc := range vectors {
+		crc := make([]byte, checksum.Size())
+		//WHEN
+		checksum.Compute([]byte(src), crc)
+		//THEN
+		if !bytes.Equal(crc, expectedCrc) {
+			t.Errorf("Crc value %v for %q is not equal to the expected one %v", crc, src, expectedCrc)
+		}
+	}
+}
+
+func TestFletcher32Checksum(t *testing.T) {
+	//GIVEN
+	vectors := map[string][]byte{
+		"abcde":    {0xF0, 0x4F, 0xC7, 0x29},
+		"abcdef":   {0x56, 0x50, 0x2D, 0x2A},
+		"abcdefgh": {0xEB, 0xE1, 0x95, 0x91},
+	}
+	checksum := NewFletcher32()
+	for src, expectedCrc := range vectors {
+		crc := make([]byte, checksum.Size())
+		//WHEN
+		checksum.Compute([]byte(src), crc)
+		//THEN
+		if !bytes.Equal(crc, expectedCrc) {
+			t.Errorf("Crc value %v for %q is not equal to the expected one %v", crc, src, expectedCrc)
+		}
+	}
+}
+
+func BenchmarkFletcher32(b *testing.B) {
+	src := []byte{1, 2, 3, 4, 5, 6}
+	checksum := NewFletcher32()
+	crc := make([]byte, checksum.Size())
+	for i := 0; i < b.N; i++ {
+		checksum.Compute(src, crc)
 	}
 }
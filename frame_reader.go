@@ -0,0 +1,89 @@
+package binproto
+
+import (
+	"bytes"
+	"io"
+)
+
+const (
+	frameDelimiter           = byte(0)
+	defaultFrameReaderBuffer = 256
+	maxConsecutiveEmptyReads = 100
+)
+
+// FrameReader splits the data read from the underlying reader into frames ended with 0 sign
+// Each frame is decoded with the given decoder and returned as a single payload
+// Bytes read after the last found delimiter are kept for the next ReadFrame call
+type FrameReader struct {
+	reader  io.Reader
+	decoder Decoder
+
+	buffer []byte
+	start  int
+	end    int
+	err    error
+}
+
+// NewFrameReader returns new FrameReader which reads frames from given reader
+// and decodes them with given decoder
+func NewFrameReader(reader io.Reader, decoder Decoder) *FrameReader {
+	return &FrameReader{reader: reader, decoder: decoder, buffer: make([]byte, defaultFrameReaderBuffer)}
+}
+
+// ReadFrame reads data from the underlying reader until the whole frame is received
+// and returns its decoded payload. Empty frames (consecutive 0 signs) are skipped.
+// Returned slice is owned by the decoder and will be overwritten by its next operation
+// If the reader ends in the middle of the frame, io.ErrUnexpectedEOF is returned
+func (f *FrameReader) ReadFrame() ([]byte, error) {
+	for {
+		if i := bytes.IndexByte(f.buffer[f.start:f.end], frameDelimiter); i >= 0 {
+			frame := f.buffer[f.start : f.start+i]
+			f.start += i + 1
+			if len(frame) == 0 {
+				continue
+			}
+			return f.decoder.Decode(frame)
+		}
+		if f.err != nil {
+			err := f.err
+			if err == io.EOF && f.Buffered() > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		f.fill()
+	}
+}
+
+// Buffered returns the number of bytes which were read from the underlying reader,
+// but are not yet consumed by ReadFrame
+func (f *FrameReader) Buffered() int {
+	return f.end - f.start
+}
+
+// fill reads next chunk of data from the underlying reader into the internal buffer
+// Unconsumed bytes are moved to the buffer start, buffer grows only if it is full
+func (f *FrameReader) fill() {
+	if f.start > 0 {
+		copy(f.buffer, f.buffer[f.start:f.end])
+		f.end -= f.start
+		f.start = 0
+	}
+	if f.end == len(f.buffer) {
+		newBuffer := make([]byte, 2*len(f.buffer))
+		copy(newBuffer, f.buffer[:f.end])
+		f.buffer = newBuffer
+	}
+	for i := 0; i < maxConsecutiveEmptyReads; i++ {
+		readLen, err := f.reader.Read(f.buffer[f.end:])
+		f.end += readLen
+		if err != nil {
+			f.err = err
+			return
+		}
+		if readLen > 0 {
+			return
+		}
+	}
+	f.err = io.ErrNoProgress
+}
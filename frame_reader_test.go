@@ -0,0 +1,121 @@
+package binproto
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encodeFrames(payloads ...[]byte) []byte {
+	encoder := NewProtocolParser()
+	var stream []byte
+	for _, payload := range payloads {
+		encoded, _ := encoder.Encode(payload)
+		stream = append(stream, encoded...)
+		stream = append(stream, 0)
+	}
+	return stream
+}
+
+func TestFrameReaderReadsFrameSpanningManyReads(t *testing.T) {
+	// GIVEN
+	payload := []byte{1, 1, 1, 0, 0, 1, 5, 12, 44}
+	stream := encodeFrames(payload)
+	reader := NewFrameReader(iotest.OneByteReader(bytes.NewReader(stream)), NewProtocolParser())
+	// WHEN
+	frame, err := reader.ReadFrame()
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, payload, frame)
+}
+
+func TestFrameReaderReadsManyFramesFromSingleRead(t *testing.T) {
+	// GIVEN
+	payloads := [][]byte{[]byte("hello"), []byte("binary"), []byte("world")}
+	stream := encodeFrames(payloads...)
+	reader := NewFrameReader(bytes.NewReader(stream), NewProtocolParser())
+	// WHEN/THEN
+	for _, payload := range payloads {
+		frame, err := reader.ReadFrame()
+		assert.Nil(t, err)
+		assert.Equal(t, payload, frame)
+	}
+	_, err := reader.ReadFrame()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestFrameReaderKeepsLeftoverBytesForNextCall(t *testing.T) {
+	// GIVEN
+	first := encodeFrames([]byte("hello"))
+	second := encodeFrames([]byte("world"))
+	pipeReader, pipeWriter := io.Pipe()
+	reader := NewFrameReader(pipeReader, NewProtocolParser())
+	go func() {
+		pipeWriter.Write(append(first, second[:3]...))
+		pipeWriter.Write(second[3:])
+		pipeWriter.Close()
+	}()
+	// WHEN
+	firstFrame, firstErr := reader.ReadFrame()
+	firstFrame = append([]byte{}, firstFrame...)
+	leftover := reader.Buffered()
+	secondFrame, secondErr := reader.ReadFrame()
+	// THEN
+	assert.Nil(t, firstErr)
+	assert.Nil(t, secondErr)
+	assert.Equal(t, 3, leftover)
+	assert.Equal(t, []byte("hello"), firstFrame)
+	assert.Equal(t, []byte("world"), secondFrame)
+}
+
+func TestFrameReaderSkipsEmptyFrames(t *testing.T) {
+	// GIVEN
+	stream := append([]byte{0, 0}, encodeFrames([]byte("hello"))...)
+	reader := NewFrameReader(bytes.NewReader(stream), NewProtocolParser())
+	// WHEN
+	frame, err := reader.ReadFrame()
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("hello"), frame)
+}
+
+func TestFrameReaderGrowsBufferForBigFrames(t *testing.T) {
+	// GIVEN
+	payload := bytes.Repeat([]byte{1, 2, 3, 0}, defaultFrameReaderBuffer)
+	stream := encodeFrames(payload)
+	reader := NewFrameReader(bytes.NewReader(stream), NewProtocolParser())
+	// WHEN
+	frame, err := reader.ReadFrame()
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, payload, frame)
+}
+
+func TestFrameReaderReturnsUnexpectedEOFOnPartialFrame(t *testing.T) {
+	// GIVEN
+	stream := encodeFrames([]byte("hello"))
+	reader := NewFrameReader(bytes.NewReader(stream[:len(stream)-1]), NewProtocolParser())
+	// WHEN
+	_, err := reader.ReadFrame()
+	// THEN
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func BenchmarkFrameReader_ReadFrame(b *testing.B) {
+	stream := encodeFrames([]byte{1, 1, 1, 0, 0, 1, 5, 12, 44})
+	source := bytes.NewReader(stream)
+	reader := NewFrameReader(source, NewProtocolParser())
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		source.Reset(stream)
+		_, err := reader.ReadFrame()
+		if err != nil {
+			b.Errorf("Failed to read frame %v. Error: %v", stream, err)
+		}
+	}
+}
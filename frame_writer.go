@@ -0,0 +1,60 @@
+package binproto
+
+import "io"
+
+// frameEncoder is implemented by encoders which are able to append the frame delimiter
+// to the encoded data in their own buffer
+type frameEncoder interface {
+	EncodeFrame([]byte) ([]byte, error)
+}
+
+// FrameWriter encodes given payloads and writes them to the underlying writer
+// as complete frames ended with 0 sign
+type FrameWriter struct {
+	writer  io.Writer
+	encoder Encoder
+	buffer  []byte
+}
+
+// NewFrameWriter returns new FrameWriter which encodes payloads with given encoder
+// and writes them to given writer
+func NewFrameWriter(writer io.Writer, encoder Encoder) *FrameWriter {
+	return &FrameWriter{writer: writer, encoder: encoder}
+}
+
+// WriteFrame encodes given payload, appends the 0 delimiter and writes the whole frame
+// If the underlying writer accepts only part of the frame, the rest is written again
+// until the whole frame is out or the writer returns an error
+func (f *FrameWriter) WriteFrame(payload []byte) error {
+	frame, err := encodeFrame(f.encoder, payload, &f.buffer)
+	if err != nil {
+		return err
+	}
+	for len(frame) > 0 {
+		written, err := f.writer.Write(frame)
+		if err != nil {
+			return err
+		}
+		if written == 0 {
+			return io.ErrShortWrite
+		}
+		frame = frame[written:]
+	}
+	return nil
+}
+
+// encodeFrame returns encoded payload with the delimiter
+// If the encoder can't append the delimiter itself, the encoded data is copied to the given buffer
+// Wrapping parsers, like SequenceParser, use it to encode frames with any wrapped parser
+func encodeFrame(encoder Encoder, payload []byte, buffer *[]byte) ([]byte, error) {
+	if frameEncoder, ok := encoder.(frameEncoder); ok {
+		return frameEncoder.EncodeFrame(payload)
+	}
+	encoded, err := encoder.Encode(payload)
+	if err != nil {
+		return nil, err
+	}
+	*buffer = append((*buffer)[:0], encoded...)
+	*buffer = append(*buffer, frameDelimiter)
+	return *buffer, nil
+}
@@ -0,0 +1,135 @@
+package binproto
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type shortWriterMock struct {
+	maxChunk int
+	calls    int
+	data     bytes.Buffer
+}
+
+func (w *shortWriterMock) Write(src []byte) (int, error) {
+	w.calls++
+	if len(src) > w.maxChunk {
+		src = src[:w.maxChunk]
+	}
+	return w.data.Write(src)
+}
+
+type encoderOnly struct {
+	encoder Encoder
+}
+
+func (e encoderOnly) Encode(src []byte) ([]byte, error) {
+	return e.encoder.Encode(src)
+}
+
+func TestFrameWriterWritesZeroEndedFrame(t *testing.T) {
+	// GIVEN
+	payload := []byte{1, 1, 1, 0, 0, 1, 5, 12, 44}
+	expectedFrame := encodeFrames(payload)
+	output := &bytes.Buffer{}
+	writer := NewFrameWriter(output, NewProtocolParser())
+	// WHEN
+	err := writer.WriteFrame(payload)
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, expectedFrame, output.Bytes())
+}
+
+func TestFrameWriterRetriesShortWrites(t *testing.T) {
+	// GIVEN
+	payload := []byte("hello")
+	expectedFrame := encodeFrames(payload)
+	output := &shortWriterMock{maxChunk: 2}
+	writer := NewFrameWriter(output, NewProtocolParser())
+	// WHEN
+	err := writer.WriteFrame(payload)
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, expectedFrame, output.data.Bytes())
+	assert.Equal(t, (len(expectedFrame)+1)/2, output.calls)
+}
+
+func TestFrameWriterFailsIfWriterDoesNotMakeProgress(t *testing.T) {
+	// GIVEN
+	output := &shortWriterMock{maxChunk: 0}
+	writer := NewFrameWriter(output, NewProtocolParser())
+	// WHEN
+	err := writer.WriteFrame([]byte("hello"))
+	// THEN
+	assert.Equal(t, io.ErrShortWrite, err)
+}
+
+func TestFrameWriterReturnsWriterError(t *testing.T) {
+	// GIVEN
+	writeError := errors.New("write failed")
+	readWriterMock := &ReadWriterMock{}
+	readWriterMock.On("Write", encodeFrames([]byte("hello"))).Return(0, writeError)
+	writer := NewFrameWriter(readWriterMock, NewProtocolParser())
+	// WHEN
+	err := writer.WriteFrame([]byte("hello"))
+	// THEN
+	assert.Equal(t, writeError, err)
+}
+
+func TestFrameWriterWorksWithAnyEncoder(t *testing.T) {
+	// GIVEN
+	payload := []byte("hello")
+	expectedFrame := encodeFrames(payload)
+	output := &bytes.Buffer{}
+	writer := NewFrameWriter(output, encoderOnly{NewProtocolParser()})
+	// WHEN
+	err := writer.WriteFrame(payload)
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, expectedFrame, output.Bytes())
+}
+
+func TestFrameWriterWorksWithWrappedParserPool(t *testing.T) {
+	// GIVEN
+	payload := []byte("hello")
+	expectedFrame := encodeFrames(append([]byte{0}, payload...))
+	output := &bytes.Buffer{}
+	writer := NewFrameWriter(output, NewSequenceParser(NewParserPool()))
+	// WHEN
+	err := writer.WriteFrame(payload)
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, expectedFrame, output.Bytes())
+}
+
+func TestFrameWriterDoesNotAllocate(t *testing.T) {
+	// GIVEN
+	payload := []byte{1, 1, 1, 0, 0, 1, 5, 12, 44}
+	writer := NewFrameWriter(io.Discard, NewProtocolParser())
+	writer.WriteFrame(payload)
+	// WHEN
+	allocs := testing.AllocsPerRun(100, func() {
+		writer.WriteFrame(payload)
+	})
+	// THEN
+	assert.Equal(t, float64(0), allocs)
+}
+
+func BenchmarkFrameWriter_WriteFrame(b *testing.B) {
+	src := []byte{1, 1, 1, 0, 0, 1, 5, 12, 44}
+	writer := NewFrameWriter(io.Discard, NewProtocolParser())
+	writer.WriteFrame(src)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		err := writer.WriteFrame(src)
+		if err != nil {
+			b.Errorf("Failed to write frame %v. Error: %v", src, err)
+		}
+	}
+}
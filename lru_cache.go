@@ -0,0 +1,117 @@
+package binproto
+
+import (
+	"container/list"
+	"unsafe"
+)
+
+// CacheStats contains cache usage counters
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	// Entries is the number of currently stored entries
+	Entries int
+	// Bytes is the number of bytes used by currently stored keys and values
+	Bytes int
+}
+
+type lruEntry struct {
+	key   string
+	value []byte
+}
+
+// lruCache is a least recently used cache limited by number of entries and bytes
+// Zero limit means that the cache is not limited by given value
+type lruCache struct {
+	maxEntries int
+	maxBytes   int
+	entries    map[string]*list.Element
+	order      *list.List
+	stats      CacheStats
+}
+
+func newLruCache(maxEntries, maxBytes int) *lruCache {
+	return &lruCache{maxEntries: maxEntries, maxBytes: maxBytes,
+		entries: make(map[string]*list.Element), order: list.New()}
+}
+
+// newLruCacheFromConfig returns nil if the cache is not enabled
+// All lruCache methods can be called on nil cache, which never stores anything
+func newLruCacheFromConfig(config CacheConfig) *lruCache {
+	if !config.Enabled {
+		return nil
+	}
+	return newLruCache(config.MaxEntries, config.MaxBytes)
+}
+
+func (c *lruCache) get(key []byte) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	keyHash := *(*string)(unsafe.Pointer(&key))
+	if element, ok := c.entries[keyHash]; ok {
+		c.order.MoveToFront(element)
+		c.stats.Hits++
+		return element.Value.(*lruEntry).value, true
+	}
+	c.stats.Misses++
+	return nil, false
+}
+
+func (c *lruCache) add(key []byte, value []byte) {
+	if c == nil {
+		return
+	}
+	entrySize := len(key) + len(value)
+	if c.maxBytes > 0 && entrySize > c.maxBytes {
+		return
+	}
+	c.remove(key)
+	// copy key to make the cache immune to future key changes
+	entry := &lruEntry{string(key), value}
+	c.entries[entry.key] = c.order.PushFront(entry)
+	c.stats.Entries++
+	c.stats.Bytes += entrySize
+
+	for (c.maxEntries > 0 && c.stats.Entries > c.maxEntries) || (c.maxBytes > 0 && c.stats.Bytes > c.maxBytes) {
+		c.removeElement(c.order.Back())
+		c.stats.Evictions++
+	}
+}
+
+func (c *lruCache) remove(key []byte) bool {
+	if c == nil {
+		return false
+	}
+	keyHash := *(*string)(unsafe.Pointer(&key))
+	if element, ok := c.entries[keyHash]; ok {
+		c.removeElement(element)
+		return true
+	}
+	return false
+}
+
+func (c *lruCache) purge() {
+	if c == nil {
+		return
+	}
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+	c.stats.Entries = 0
+	c.stats.Bytes = 0
+}
+
+func (c *lruCache) getStats() CacheStats {
+	if c == nil {
+		return CacheStats{}
+	}
+	return c.stats
+}
+
+func (c *lruCache) removeElement(element *list.Element) {
+	entry := c.order.Remove(element).(*lruEntry)
+	delete(c.entries, entry.key)
+	c.stats.Entries--
+	c.stats.Bytes -= len(entry.key) + len(entry.value)
+}
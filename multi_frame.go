@@ -0,0 +1,151 @@
+package binproto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var (
+	// ErrNoCompletionCondition is returned when multi-frame transaction has neither Complete predicate nor MaxFrames set
+	ErrNoCompletionCondition = errors.New("multi-frame response requires Complete predicate or MaxFrames")
+	// ErrFrameHandler wraps the error returned by the OnFrame callback. Such transaction is not retried
+	ErrFrameHandler = errors.New("frame handler failed")
+)
+
+// MultiFrameOptions describes when the multi-frame response is complete and how its frames are delivered
+type MultiFrameOptions struct {
+	// Complete is called with each received frame and returns true if it was the last frame of the response
+	Complete func(frame []byte) bool
+	// MaxFrames ends the response after given number of frames. Zero means no limit
+	MaxFrames int
+	// FrameTimeout limits the wait for each frame. Zero means the ProtocolReadWriter read timeout
+	FrameTimeout time.Duration
+	// Timeout limits the whole response of single attempt. Zero means no limit
+	Timeout time.Duration
+	// OnFrame, if set, receives each frame instead of collecting them. Given slice is valid only until it returns
+	// If the transaction is retried, frames are delivered again from the first one
+	OnFrame func(frame []byte) error
+}
+
+// TransactMulti encodes given payload, writes it as a request frame and collects the response frames
+// until the Complete predicate returns true or MaxFrames frames are received
+// Whole exchange is retried according to the retry policy, frames received by failed attempts are dropped
+// Returned frames are owned by the caller. If OnFrame callback is set, nil slice is returned
+func (p *ProtocolReadWriter) TransactMulti(readWriter io.ReadWriter, payload []byte, options MultiFrameOptions) ([][]byte, error) {
+	return p.TransactMultiContext(context.Background(), readWriter, payload, options)
+}
+
+// TransactMultiContext works like TransactMulti, but stops as soon as given context is done
+func (p *ProtocolReadWriter) TransactMultiContext(ctx context.Context, readWriter io.ReadWriter, payload []byte, options MultiFrameOptions) ([][]byte, error) {
+	if options.Complete == nil && options.MaxFrames <= 0 {
+		return nil, ErrNoCompletionCondition
+	}
+	request, err := p.encodeRequest(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var frameEnds []int
+	err = RetryWithPolicy(ctx, handlerAbortPolicy{p.retryPolicy}, func() error {
+		p.messageBuffer.Reset()
+		frameEnds = frameEnds[:0]
+		deadlines := p.newMultiFrameDeadlines(options)
+		// local echo and the first frame share the same deadline
+		firstDeadline := deadlines.next()
+		if err := p.writeRequest(ctx, readWriter, request, firstDeadline); err != nil {
+			return err
+		}
+		return p.receiveFrames(ctx, readWriter, options, deadlines, firstDeadline, &frameEnds)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if options.OnFrame != nil {
+		return nil, nil
+	}
+
+	// all frames share single copy of the message buffer
+	messages := append([]byte{}, p.messageBuffer.Bytes()...)
+	frames := make([][]byte, len(frameEnds))
+	start := 0
+	for i, end := range frameEnds {
+		frames[i] = messages[start:end:end]
+		start = end
+	}
+	return frames, nil
+}
+
+// multiFrameDeadlines limits the wait for each frame and the whole multi-frame response
+type multiFrameDeadlines struct {
+	frameTimeout time.Duration
+	response     time.Time
+}
+
+func (p *ProtocolReadWriter) newMultiFrameDeadlines(options MultiFrameOptions) multiFrameDeadlines {
+	deadlines := multiFrameDeadlines{frameTimeout: options.FrameTimeout}
+	if deadlines.frameTimeout <= 0 {
+		deadlines.frameTimeout = p.readTimeout
+	}
+	if options.Timeout > 0 {
+		deadlines.response = time.Now().Add(options.Timeout)
+	}
+	return deadlines
+}
+
+// next returns the deadline of the frame awaited from now
+func (d multiFrameDeadlines) next() time.Time {
+	frameDeadline := time.Now().Add(d.frameTimeout)
+	if !d.response.IsZero() && d.response.Before(frameDeadline) {
+		return d.response
+	}
+	return frameDeadline
+}
+
+// receiveFrames receives response frames until the response is complete
+// First frame is awaited until given deadline, the next ones until the deadlines computed after each frame
+// End offsets of the frames stored in the message buffer are appended to frameEnds
+func (p *ProtocolReadWriter) receiveFrames(ctx context.Context, reader io.Reader, options MultiFrameOptions,
+	deadlines multiFrameDeadlines, frameDeadline time.Time, frameEnds *[]int) error {
+	mode := p.responseMode()
+	for received := 0; ; received++ {
+		if received > 0 {
+			frameDeadline = deadlines.next()
+		}
+		frameStart := p.messageBuffer.Len()
+		if err := p.receiveMessage(ctx, reader, frameDeadline, mode); err != nil {
+			if received > 0 {
+				return fmt.Errorf("%w. Frames received: %v", err, received)
+			}
+			return err
+		}
+		// device already responded, so the next frames are awaited until the deadline
+		mode = continuationMode
+
+		frame := p.messageBuffer.Bytes()[frameStart:]
+		complete := (options.MaxFrames > 0 && received+1 >= options.MaxFrames) ||
+			(options.Complete != nil && options.Complete(frame))
+		if options.OnFrame != nil {
+			if err := options.OnFrame(frame); err != nil {
+				return fmt.Errorf("%w: %w", ErrFrameHandler, err)
+			}
+			p.messageBuffer.Truncate(frameStart)
+		} else {
+			*frameEnds = append(*frameEnds, p.messageBuffer.Len())
+		}
+		if complete {
+			return nil
+		}
+	}
+}
+
+// handlerAbortPolicy stops retrying when the frame handler fails, as the failure is not caused by the transport
+type handlerAbortPolicy struct {
+	RetryPolicy
+}
+
+func (h handlerAbortPolicy) Retryable(err error) bool {
+	return !errors.Is(err, ErrFrameHandler) && h.RetryPolicy.Retryable(err)
+}
@@ -0,0 +1,122 @@
+package binproto
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactMultiShouldCollectFramesUntilComplete(t *testing.T) {
+	// GIVEN
+	expectedFrames := [][]byte{[]byte("part1"), []byte("part2"), []byte("end")}
+	readWriterMock := &DeviceReadWriterMock{responses: [][]byte{encodeFrames(expectedFrames...)}}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second)
+	options := MultiFrameOptions{Complete: func(frame []byte) bool { return bytes.Equal(frame, []byte("end")) }}
+
+	// WHEN
+	frames, err := readWriter.TransactMulti(readWriterMock, []byte("list"), options)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, expectedFrames, frames)
+}
+
+func TestTransactMultiShouldStopAfterMaxFrames(t *testing.T) {
+	// GIVEN
+	readWriterMock := &DeviceReadWriterMock{
+		responses: [][]byte{encodeFrames([]byte("first"), []byte("second"), []byte("notification"))},
+	}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second)
+
+	// WHEN
+	frames, err := readWriter.TransactMulti(readWriterMock, []byte("list"), MultiFrameOptions{MaxFrames: 2})
+	pending, pendingErr := readWriter.ReadPending()
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{[]byte("first"), []byte("second")}, frames)
+	assert.Nil(t, pendingErr)
+	assert.Equal(t, []byte("notification"), pending)
+}
+
+func TestTransactMultiShouldDeliverFramesToCallback(t *testing.T) {
+	// GIVEN
+	expectedFrames := [][]byte{[]byte("part1"), []byte("part2")}
+	readWriterMock := &DeviceReadWriterMock{responses: [][]byte{encodeFrames(expectedFrames...)}}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second)
+	var delivered [][]byte
+	options := MultiFrameOptions{MaxFrames: 2, OnFrame: func(frame []byte) error {
+		delivered = append(delivered, append([]byte{}, frame...))
+		return nil
+	}}
+
+	// WHEN
+	frames, err := readWriter.TransactMulti(readWriterMock, []byte("list"), options)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Nil(t, frames)
+	assert.Equal(t, expectedFrames, delivered)
+}
+
+func TestTransactMultiShouldNotRetryOnCallbackError(t *testing.T) {
+	// GIVEN
+	readWriterMock := &DeviceReadWriterMock{
+		responses: [][]byte{encodeFrames([]byte("part1")), encodeFrames([]byte("part1"))},
+	}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 2, 0, 0, 1*time.Second)
+	handlerErr := errors.New("storage full")
+	options := MultiFrameOptions{MaxFrames: 2, OnFrame: func(frame []byte) error { return handlerErr }}
+
+	// WHEN
+	_, err := readWriter.TransactMulti(readWriterMock, []byte("list"), options)
+
+	// THEN
+	assert.ErrorIs(t, err, ErrFrameHandler)
+	assert.ErrorIs(t, err, handlerErr)
+	assert.Equal(t, 1, len(readWriterMock.responses))
+}
+
+func TestTransactMultiShouldTimeoutWaitingForNextFrame(t *testing.T) {
+	// GIVEN
+	readWriterMock := &DeviceReadWriterMock{responses: [][]byte{encodeFrames([]byte("part1"))}}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second)
+	options := MultiFrameOptions{MaxFrames: 2, FrameTimeout: 20 * time.Millisecond}
+
+	// WHEN
+	start := time.Now()
+	_, err := readWriter.TransactMulti(readWriterMock, []byte("list"), options)
+
+	// THEN
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestTransactMultiShouldRespectOverallTimeout(t *testing.T) {
+	// GIVEN
+	readWriterMock := &DeviceReadWriterMock{responses: [][]byte{encodeFrames([]byte("part1"))}}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second)
+	options := MultiFrameOptions{MaxFrames: 2, Timeout: 20 * time.Millisecond}
+
+	// WHEN
+	start := time.Now()
+	_, err := readWriter.TransactMulti(readWriterMock, []byte("list"), options)
+
+	// THEN
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestTransactMultiShouldRequireCompletionCondition(t *testing.T) {
+	// GIVEN
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second)
+
+	// WHEN
+	_, err := readWriter.TransactMulti(&DeviceReadWriterMock{}, []byte("list"), MultiFrameOptions{})
+
+	// THEN
+	assert.Equal(t, ErrNoCompletionCondition, err)
+}
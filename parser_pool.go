@@ -0,0 +1,81 @@
+package binproto
+
+import "sync"
+
+// ParserPool is a concurrency safe encoder/decoder backed by the pool of ProtocolParser objects
+// Each Encode/Decode call borrows a parser from the pool, so many goroutines can use it at once
+// Unlike ProtocolParser, returned slices are owned by the caller and are never overwritten
+type ParserPool struct {
+	pool        sync.Pool
+	checksumLen int
+}
+
+// NewParserPool returns new ParserPool which uses Fletcher-16 checksum
+func NewParserPool() *ParserPool {
+	return NewParserPoolWithChecksum(NewFletcher16())
+}
+
+// NewParserPoolWithChecksum returns new ParserPool which uses given checksum
+// Checksum instance is shared between all pooled parsers
+func NewParserPoolWithChecksum(checksum Checksum) *ParserPool {
+	pool := &ParserPool{checksumLen: checksum.Size()}
+	pool.pool.New = func() interface{} {
+		return NewProtocolParserWithChecksum(checksum)
+	}
+	return pool
+}
+
+// Encode encodes given source slice with COBS encoding and adds checksum
+// ! This function will allocate a new buffer for each call to return the result
+func (p *ParserPool) Encode(src []byte) ([]byte, error) {
+	proto := p.pool.Get().(*ProtocolParser)
+	defer p.pool.Put(proto)
+
+	if _, err := proto.Encode(src); err != nil {
+		return nil, err
+	}
+	return proto.Copy(), nil
+}
+
+// Decode decodes given source slice, which was previously encoded with COBS encoding
+// ! This function will allocate a new buffer for each call to return the result
+func (p *ParserPool) Decode(src []byte) ([]byte, error) {
+	proto := p.pool.Get().(*ProtocolParser)
+	defer p.pool.Put(proto)
+
+	decoded, err := proto.Decode(src)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]byte, len(decoded))
+	copy(result, decoded)
+	return result, nil
+}
+
+// AppendEncode encodes given source slice and appends the result to dst
+// If dst has at least MaxEncodedLen(len(src)) free capacity, no memory is allocated
+func (p *ParserPool) AppendEncode(dst, src []byte) ([]byte, error) {
+	proto := p.pool.Get().(*ProtocolParser)
+	defer p.pool.Put(proto)
+
+	return proto.AppendEncode(dst, src)
+}
+
+// AppendDecode decodes given source slice and appends the result to dst
+// If dst has at least MaxDecodedLen(len(src)) free capacity, no memory is allocated
+func (p *ParserPool) AppendDecode(dst, src []byte) ([]byte, error) {
+	proto := p.pool.Get().(*ProtocolParser)
+	defer p.pool.Put(proto)
+
+	return proto.AppendDecode(dst, src)
+}
+
+// MaxEncodedLen returns the maximum length of encoded data for the payload of given length
+func (p *ParserPool) MaxEncodedLen(payloadLen int) int {
+	return maxEncodedLen(payloadLen, p.checksumLen)
+}
+
+// MaxDecodedLen returns the maximum length of payload decoded from the data of given length
+func (p *ParserPool) MaxDecodedLen(encodedLen int) int {
+	return maxDecodedLen(encodedLen, p.checksumLen)
+}
@@ -0,0 +1,127 @@
+package binproto
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestParserPoolEncodeDecodePositive(t *testing.T) {
+	//GIVEN
+	src := []byte{1, 1, 1, 0, 0, 1, 5, 12, 44}
+	pool := NewParserPool()
+	//WHEN
+	encoded, _ := pool.Encode(src)
+	decoded, _ := pool.Decode(encoded)
+	//THEN
+	if !bytes.Equal(decoded, src) {
+		t.Errorf("Decoded array %v does not equal to the source %v", decoded, src)
+	}
+}
+
+func TestParserPoolResultsAreOwnedByCaller(t *testing.T) {
+	//GIVEN
+	pool := NewParserPool()
+	first, _ := pool.Encode([]byte("hello"))
+	firstSave := append([]byte{}, first...)
+	//WHEN
+	pool.Encode([]byte("world"))
+	//THEN
+	if !bytes.Equal(first, firstSave) {
+		t.Errorf("Encoded array %v was overwritten by the next call, expected %v", first, firstSave)
+	}
+}
+
+func TestParserPoolConcurrentUse(t *testing.T) {
+	//GIVEN
+	pool := NewParserPoolWithChecksum(NewCRC16CCITT())
+	workers := 8
+	iterations := 100
+	errs := make(chan error, workers)
+	wg := sync.WaitGroup{}
+	//WHEN
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				src := []byte(fmt.Sprintf("worker %v message %v", worker, i))
+				encoded, err := pool.Encode(src)
+				if err != nil {
+					errs <- err
+					return
+				}
+				decoded, err := pool.Decode(encoded)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if !bytes.Equal(decoded, src) {
+					errs <- fmt.Errorf("decoded array %v does not equal to the source %v", decoded, src)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	//THEN
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestSeparateParsersCanBeUsedConcurrently(t *testing.T) {
+	//GIVEN
+	src := []byte{1, 1, 1, 0, 0, 1, 5, 12, 44}
+	wg := sync.WaitGroup{}
+	//WHEN/THEN
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			proto := NewProtocolParser()
+			for i := 0; i < 100; i++ {
+				encoded, _ := proto.Encode(src)
+				encodedSave := append([]byte{}, encoded...)
+				decoded, err := proto.Decode(encodedSave)
+				if err != nil || !bytes.Equal(decoded, src) {
+					t.Errorf("Decoded array %v does not equal to the source %v. Error: %v", decoded, src, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkParserPool_Encode(b *testing.B) {
+	src := []byte{1, 1, 1, 0, 0, 1, 5, 12, 44}
+	pool := NewParserPool()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, err := pool.Encode(src)
+			if err != nil {
+				b.Errorf("Failed to encode source array %v. Error: %v", src, err)
+			}
+		}
+	})
+}
+
+func TestParserPoolAppendEncodeDecode(t *testing.T) {
+	//GIVEN
+	src := []byte{1, 1, 1, 0, 0, 1, 5, 12, 44}
+	pool := NewParserPool()
+	//WHEN
+	encoded, _ := pool.AppendEncode(make([]byte, 0, pool.MaxEncodedLen(len(src))), src)
+	decoded, err := pool.AppendDecode(make([]byte, 0, pool.MaxDecodedLen(len(encoded))), encoded)
+	//THEN
+	if err != nil {
+		t.Error("append decoding failed: ", err)
+	}
+	if !bytes.Equal(decoded, src) {
+		t.Errorf("Decoded array %v does not equal to the source %v", decoded, src)
+	}
+}
@@ -0,0 +1,279 @@
+package binproto
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPollBackoffInitial = 100 * time.Millisecond
+	defaultPollBackoffMax     = 10 * time.Second
+)
+
+// ErrInvalidPollInterval is returned when the poll job has no positive interval
+var ErrInvalidPollInterval = errors.New("poll job interval must be positive")
+
+// PollHandler is called with the response of the poll job, or with the error if the transaction failed
+// Response is valid only until the handler returns
+type PollHandler func(response []byte, err error)
+
+// PollJob describes the request sent cyclically to the device
+type PollJob struct {
+	// Address of the device. It is set as the destination if ProtocolReadWriter uses AddressParser,
+	// so it can't be the BroadcastAddress
+	// Jobs with the same address share the device backoff
+	Address byte
+	// Payload is the request sent on each run
+	Payload []byte
+	// Interval is the time between the scheduled runs
+	Interval time.Duration
+	// Priority decides which job runs first when many are due. Higher value means higher priority
+	// Due job gains one priority point for each run of other job, so busy high priority jobs can't starve it
+	Priority int
+	// Handler receives the result of each run
+	Handler PollHandler
+}
+
+// PollStats contains the statistics of single poll job
+// Latency is measured for successful runs only
+type PollStats struct {
+	Runs     uint64
+	Failures uint64
+	// Skipped is the number of scheduled runs missed because of overrun or device backoff
+	Skipped uint64
+
+	LastLatency  time.Duration
+	MinLatency   time.Duration
+	MaxLatency   time.Duration
+	TotalLatency time.Duration
+}
+
+// AverageLatency returns the average latency of successful runs
+func (s PollStats) AverageLatency() time.Duration {
+	successful := s.Runs - s.Failures
+	if successful == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(successful)
+}
+
+type pollJobState struct {
+	job   PollJob
+	next  time.Time
+	stats PollStats
+	// age is the number of runs of other jobs since this job became due
+	age int
+}
+
+// priority returns the job priority raised by its age
+func (s *pollJobState) priority() int {
+	return s.job.Priority + s.age
+}
+
+// deadline returns the time until which the current run must start, before it is skipped
+func (s *pollJobState) deadline() time.Time {
+	return s.next.Add(s.job.Interval)
+}
+
+type pollDevice struct {
+	failures int
+	delay    time.Duration
+	until    time.Time
+}
+
+// Poller runs poll jobs cyclically on the single ProtocolReadWriter
+// Due job with the highest priority runs first, jobs with equal priority run in order of their deadlines
+// Priority of the due job grows with each run of other job, until it runs, so every due job runs eventually
+// Runs which couldn't start before the next scheduled one are skipped
+// When the device stops responding, its jobs are suspended for the time given by the backoff policy
+type Poller struct {
+	readWriter *ProtocolReadWriter
+	// addressParser sets the destination of the requests, it is nil if the bus is not addressed
+	addressParser *AddressParser
+	port          io.ReadWriter
+	backoff       RetryPolicy
+
+	mu      sync.Mutex
+	jobs    []*pollJobState
+	devices map[byte]*pollDevice
+	wake    chan struct{}
+}
+
+// NewPoller returns new Poller which sends requests through given ProtocolReadWriter and port
+// Device backoff starts from 100ms and is doubled after each failure, up to 10s
+func NewPoller(readWriter *ProtocolReadWriter, port io.ReadWriter) *Poller {
+	addressParser, _ := findDecoder[*AddressParser](readWriter.decoder)
+	return &Poller{
+		readWriter:    readWriter,
+		addressParser: addressParser,
+		port:          port,
+		backoff:       NewExponentialBackoff(0, defaultPollBackoffInitial, defaultPollBackoffMax),
+		devices:       make(map[byte]*pollDevice),
+		wake:          make(chan struct{}, 1),
+	}
+}
+
+// SetBackoff sets the policy used to suspend jobs of the device which stopped responding
+// Only NextDelay of the policy is used, with the number of consecutive device failures
+func (p *Poller) SetBackoff(backoff RetryPolicy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.backoff = backoff
+}
+
+// Add adds the job, which will be run as soon as possible and then every Interval
+// Returned id can be used to read the job statistics. Jobs can be added while the Poller is running
+// ErrBroadcastTransaction is returned for the job sent to the broadcast address, as no device responds to it
+func (p *Poller) Add(job PollJob) (int, error) {
+	if job.Interval <= 0 {
+		return 0, ErrInvalidPollInterval
+	}
+	if p.addressParser != nil && job.Address == BroadcastAddress {
+		return 0, ErrBroadcastTransaction
+	}
+	p.mu.Lock()
+	p.jobs = append(p.jobs, &pollJobState{job: job, next: time.Now()})
+	id := len(p.jobs) - 1
+	p.mu.Unlock()
+
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+	return id, nil
+}
+
+// Stats returns the statistics of the job with given id
+func (p *Poller) Stats(id int) (PollStats, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if id < 0 || id >= len(p.jobs) {
+		return PollStats{}, false
+	}
+	return p.jobs[id].stats, true
+}
+
+// Run runs the jobs until given context is done or the transport fails with not retryable error
+// Context error is returned in the first case and transport error in the second one
+// Only one Run may be active at the same time
+func (p *Poller) Run(ctx context.Context) error {
+	timer := time.NewTimer(time.Hour)
+	stopTimer(timer)
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		state, wait := p.nextJob(time.Now())
+		if state != nil {
+			if err := p.runJob(ctx, state); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var timeout <-chan time.Time
+		if wait > 0 {
+			timer.Reset(wait)
+			timeout = timer.C
+		}
+		select {
+		case <-timeout:
+		case <-p.wake:
+			stopTimer(timer)
+		case <-ctx.Done():
+			stopTimer(timer)
+			return ctx.Err()
+		}
+	}
+}
+
+// nextJob returns the due job which should run now
+// If there is no such job, the time to wait for the next one is returned. Zero means there are no jobs
+func (p *Poller) nextJob(now time.Time) (*pollJobState, time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var selected *pollJobState
+	var wait time.Duration
+	for _, state := range p.jobs {
+		// runs which missed their deadline are skipped
+		if overrun := now.Sub(state.next); overrun >= state.job.Interval {
+			skipped := overrun / state.job.Interval
+			state.stats.Skipped += uint64(skipped)
+			state.next = state.next.Add(skipped * state.job.Interval)
+		}
+
+		readyAt := state.next
+		if device, ok := p.devices[state.job.Address]; ok && device.until.After(readyAt) {
+			readyAt = device.until
+		}
+		if readyAt.After(now) {
+			if untilReady := readyAt.Sub(now); wait == 0 || untilReady < wait {
+				wait = untilReady
+			}
+			continue
+		}
+		// due jobs are aged, the selected one is reset below, so the ones passed over can't be starved
+		state.age++
+		if selected == nil || state.priority() > selected.priority() ||
+			(state.priority() == selected.priority() && state.deadline().Before(selected.deadline())) {
+			selected = state
+		}
+	}
+
+	if selected != nil {
+		selected.age = 0
+	}
+	return selected, wait
+}
+
+// runJob runs single transaction of the job and updates its statistics and the device backoff
+// Error is returned only if polling can't be continued
+func (p *Poller) runJob(ctx context.Context, state *pollJobState) error {
+	if p.addressParser != nil {
+		p.addressParser.SetDestination(state.job.Address)
+	}
+	start := time.Now()
+	response, err := p.readWriter.TransactContext(ctx, p.port, state.job.Payload)
+	latency := time.Since(start)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	p.mu.Lock()
+	state.next = state.next.Add(state.job.Interval)
+	state.stats.Runs++
+	device := p.devices[state.job.Address]
+	if err != nil {
+		state.stats.Failures++
+		if device == nil {
+			device = &pollDevice{}
+			p.devices[state.job.Address] = device
+		}
+		device.failures++
+		device.delay = p.backoff.NextDelay(device.failures, device.delay)
+		device.until = time.Now().Add(device.delay)
+	} else {
+		state.stats.LastLatency = latency
+		state.stats.TotalLatency += latency
+		if state.stats.MinLatency == 0 || latency < state.stats.MinLatency {
+			state.stats.MinLatency = latency
+		}
+		if latency > state.stats.MaxLatency {
+			state.stats.MaxLatency = latency
+		}
+		delete(p.devices, state.job.Address)
+	}
+	p.mu.Unlock()
+
+	if state.job.Handler != nil {
+		state.job.Handler(response, err)
+	}
+	if err != nil && !DefaultRetryable(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package binproto
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// PollDeviceMock decodes each written request and queues the frame returned by respond as the response
+// Nil response means that the device doesn't answer
+type PollDeviceMock struct {
+	parser  *ProtocolParser
+	respond func(request []byte) []byte
+	input   []byte
+}
+
+func newPollDeviceMock(respond func(request []byte) []byte) *PollDeviceMock {
+	return &PollDeviceMock{parser: NewProtocolParser(), respond: respond}
+}
+
+func (rw *PollDeviceMock) Write(src []byte) (int, error) {
+	request, err := rw.parser.Decode(src[:len(src)-1])
+	if err != nil {
+		return 0, err
+	}
+	if response := rw.respond(request); response != nil {
+		frame, _ := rw.parser.EncodeFrame(response)
+		rw.input = append(rw.input, frame...)
+	}
+	return len(src), nil
+}
+
+func (rw *PollDeviceMock) Read(dst []byte) (int, error) {
+	readLen := copy(dst, rw.input)
+	rw.input = rw.input[readLen:]
+	return readLen, io.EOF
+}
+
+func runPoller(poller *Poller, duration time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+	return poller.Run(ctx)
+}
+
+func TestPollerShouldRunJobEveryInterval(t *testing.T) {
+	// GIVEN
+	device := newPollDeviceMock(func(request []byte) []byte { return []byte("value") })
+	poller := NewPoller(NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second), device)
+	var responses []string
+	id, addErr := poller.Add(PollJob{Payload: []byte("read"), Interval: 20 * time.Millisecond, Handler: func(response []byte, err error) {
+		assert.Nil(t, err)
+		responses = append(responses, string(response))
+	}})
+
+	// WHEN
+	err := runPoller(poller, 90*time.Millisecond)
+	stats, ok := poller.Stats(id)
+
+	// THEN
+	assert.Nil(t, addErr)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.True(t, ok)
+	assert.GreaterOrEqual(t, len(responses), 3)
+	assert.LessOrEqual(t, len(responses), 6)
+	assert.Equal(t, "value", responses[0])
+	assert.Equal(t, uint64(len(responses)), stats.Runs)
+	assert.Equal(t, uint64(0), stats.Failures)
+	assert.LessOrEqual(t, stats.MinLatency, stats.AverageLatency())
+	assert.LessOrEqual(t, stats.AverageLatency(), stats.MaxLatency)
+}
+
+func TestPollerShouldRunHigherPriorityJobFirst(t *testing.T) {
+	// GIVEN
+	device := newPollDeviceMock(func(request []byte) []byte { return request })
+	poller := NewPoller(NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second), device)
+	var order []string
+	handler := func(response []byte, err error) {
+		order = append(order, string(response))
+	}
+	poller.Add(PollJob{Payload: []byte("low"), Interval: time.Second, Priority: 1, Handler: handler})
+	poller.Add(PollJob{Payload: []byte("high"), Interval: time.Second, Priority: 5, Handler: handler})
+
+	// WHEN
+	runPoller(poller, 20*time.Millisecond)
+
+	// THEN
+	assert.Equal(t, []string{"high", "low"}, order)
+}
+
+func TestPollerShouldRunJobWithEarlierDeadlineFirst(t *testing.T) {
+	// GIVEN
+	device := newPollDeviceMock(func(request []byte) []byte { return request })
+	poller := NewPoller(NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second), device)
+	var order []string
+	handler := func(response []byte, err error) {
+		order = append(order, string(response))
+	}
+	poller.Add(PollJob{Payload: []byte("slow"), Interval: 10 * time.Second, Handler: handler})
+	poller.Add(PollJob{Payload: []byte("fast"), Interval: time.Second, Handler: handler})
+
+	// WHEN
+	runPoller(poller, 20*time.Millisecond)
+
+	// THEN
+	assert.Equal(t, []string{"fast", "slow"}, order)
+}
+
+func TestPollerShouldBackOffDeviceWhichStoppedResponding(t *testing.T) {
+	// GIVEN
+	parser := NewAddressParser(NewProtocolParser(), 1)
+	device := newPollDeviceMock(func(request []byte) []byte {
+		// only device 7 responds, swapping the addresses
+		if request[0] != 7 {
+			return nil
+		}
+		return append([]byte{request[1], request[0]}, request[2:]...)
+	})
+	poller := NewPoller(NewProtocolReadWriterWithPolicy(parser, NewConstantBackoff(1, 0), 0, 1*time.Second), device)
+	poller.SetBackoff(NewConstantBackoff(0, time.Hour))
+	working, _ := poller.Add(PollJob{Address: 7, Payload: []byte("read"), Interval: 10 * time.Millisecond})
+	broken, _ := poller.Add(PollJob{Address: 8, Payload: []byte("read"), Interval: 10 * time.Millisecond})
+
+	// WHEN
+	runPoller(poller, 55*time.Millisecond)
+	workingStats, _ := poller.Stats(working)
+	brokenStats, _ := poller.Stats(broken)
+
+	// THEN
+	assert.Equal(t, uint64(0), workingStats.Failures)
+	assert.GreaterOrEqual(t, workingStats.Runs, uint64(3))
+	assert.Equal(t, uint64(1), brokenStats.Runs)
+	assert.Equal(t, uint64(1), brokenStats.Failures)
+	assert.GreaterOrEqual(t, brokenStats.Skipped, uint64(3))
+}
+
+func TestPollerShouldNotStarveLowerPriorityJob(t *testing.T) {
+	// GIVEN
+	device := newPollDeviceMock(func(request []byte) []byte { return request })
+	poller := NewPoller(NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second), device)
+	// high priority job takes longer than its interval, so it is always due
+	high, _ := poller.Add(PollJob{Payload: []byte("high"), Interval: time.Millisecond, Priority: 3, Handler: func([]byte, error) {
+		time.Sleep(2 * time.Millisecond)
+	}})
+	low, _ := poller.Add(PollJob{Payload: []byte("low"), Interval: 5 * time.Millisecond, Priority: 1})
+
+	// WHEN
+	runPoller(poller, 60*time.Millisecond)
+	highStats, _ := poller.Stats(high)
+	lowStats, _ := poller.Stats(low)
+
+	// THEN
+	assert.GreaterOrEqual(t, lowStats.Runs, uint64(2))
+	assert.Greater(t, highStats.Runs, lowStats.Runs)
+}
+
+func TestPollerShouldSkipOverrunJobs(t *testing.T) {
+	// GIVEN
+	device := newPollDeviceMock(func(request []byte) []byte { return request })
+	poller := NewPoller(NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second), device)
+	id, _ := poller.Add(PollJob{Payload: []byte("read"), Interval: 5 * time.Millisecond, Handler: func([]byte, error) {
+		time.Sleep(20 * time.Millisecond)
+	}})
+
+	// WHEN
+	runPoller(poller, 50*time.Millisecond)
+	stats, _ := poller.Stats(id)
+
+	// THEN
+	assert.GreaterOrEqual(t, stats.Skipped, uint64(3))
+	assert.LessOrEqual(t, stats.Runs, uint64(3))
+}
+
+func TestPollerShouldStopOnClosedTransport(t *testing.T) {
+	// GIVEN
+	readWriterMock := new(ReadWriterMock)
+	readWriterMock.On("Write", mock.Anything).Return(0, io.ErrClosedPipe)
+	poller := NewPoller(NewProtocolReadWriter(NewProtocolParser(), 3, 0, 0, 1*time.Second), readWriterMock)
+	poller.Add(PollJob{Payload: []byte("read"), Interval: 10 * time.Millisecond})
+
+	// WHEN
+	err := runPoller(poller, time.Second)
+
+	// THEN
+	assert.ErrorIs(t, err, io.ErrClosedPipe)
+}
+
+func TestPollerShouldRejectJobWithoutInterval(t *testing.T) {
+	// GIVEN
+	poller := NewPoller(NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second), &DeviceReadWriterMock{})
+
+	// WHEN
+	_, err := poller.Add(PollJob{Payload: []byte("read")})
+
+	// THEN
+	assert.Equal(t, ErrInvalidPollInterval, err)
+}
+
+func TestPollerShouldRejectJobWithBroadcastAddress(t *testing.T) {
+	// GIVEN
+	parser := NewAddressParser(NewProtocolParser(), 1)
+	poller := NewPoller(NewProtocolReadWriter(parser, 1, 0, 0, 1*time.Second), &DeviceReadWriterMock{})
+
+	// WHEN
+	_, err := poller.Add(PollJob{Address: BroadcastAddress, Payload: []byte("read"), Interval: time.Second})
+
+	// THEN
+	assert.Equal(t, ErrBroadcastTransaction, err)
+}
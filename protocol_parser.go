@@ -3,6 +3,7 @@ package binproto
 import (
 	"bytes"
 	"fmt"
+	"slices"
 )
 
 // Encoder encodes given bytes slice into new data format
@@ -25,14 +26,24 @@ type EncodeDecoder interface {
 
 // ProtocolParser implements COBS encoder/decoder with crc checksum
 type ProtocolParser struct {
+	checksum  Checksum
 	buffer    []byte
 	crcBuffer []byte
+	sumBuffer []byte
 	lastPos   int
+	// tailBuffer receives the checksum bytes which don't fit into the destination of AppendDecode
+	tailBuffer []byte
 }
 
-// NewProtocolParser returns new BinProto object
+// NewProtocolParser returns new BinProto object which uses Fletcher-16 checksum
 func NewProtocolParser() (binProto *ProtocolParser) {
-	return &ProtocolParser{[]byte{}, []byte{}, 0}
+	return NewProtocolParserWithChecksum(NewFletcher16())
+}
+
+// NewProtocolParserWithChecksum returns new BinProto object which uses given checksum
+// Both sides of the communication must use the same checksum algorithm
+func NewProtocolParserWithChecksum(checksum Checksum) (binProto *ProtocolParser) {
+	return &ProtocolParser{checksum, []byte{}, []byte{}, make([]byte, checksum.Size()), 0, make([]byte, checksum.Size()+1)}
 }
 
 // Encode encodes given source slice with COBS encoding
@@ -40,27 +51,51 @@ func NewProtocolParser() (binProto *ProtocolParser) {
 // Encoded data is stored in the internal buffer and pointer for it is returned
 // If one wants to store the data for later use, Copy function must be used
 func (proto *ProtocolParser) Encode(src []byte) ([]byte, error) {
-	srcWithChecksumLen := len(src) + crcLen
-	requiredBufferLen := cobsGetEncodedBufferSize(srcWithChecksumLen)
+	requiredBufferLen := proto.MaxEncodedLen(len(src))
 	if len(proto.buffer) < requiredBufferLen {
 		proto.buffer = make([]byte, requiredBufferLen)
 	}
+	encodedLen, err := proto.encodeTo(src, proto.buffer)
+	if err != nil {
+		return nil, err
+	}
+	proto.lastPos = encodedLen
+	return proto.buffer[:encodedLen], nil
+}
+
+// encodeTo joins the checksum with the source and encodes them to the dest, returning encoded length
+func (proto *ProtocolParser) encodeTo(src []byte, dest []byte) (int, error) {
+	srcLen := len(src)
 	proto.crcBuffer = proto.crcBuffer[:0]
 	proto.crcBuffer = append(proto.crcBuffer, src...)
-	proto.crcBuffer = append(proto.crcBuffer, fletcher16(src)...)
+	proto.crcBuffer = append(proto.crcBuffer, proto.sumBuffer...)
+	proto.checksum.Compute(src, proto.crcBuffer[srcLen:])
+	return cobsEncode(proto.crcBuffer[:srcLen+proto.checksum.Size()], dest)
+}
 
-	encodedLen, err := cobsEncode(proto.crcBuffer[:srcWithChecksumLen], proto.buffer)
+// EncodeFrame works like Encode, but the encoded data is ended with 0 sign
+// so it can be written directly to the output stream as a complete frame
+// Delimiter is written to the same internal buffer, so no additional memory is allocated
+func (proto *ProtocolParser) EncodeFrame(src []byte) ([]byte, error) {
+	requiredBufferLen := proto.MaxEncodedLen(len(src)) + 1
+	if len(proto.buffer) < requiredBufferLen {
+		proto.buffer = make([]byte, requiredBufferLen)
+	}
+	encoded, err := proto.Encode(src)
 	if err != nil {
 		return nil, err
 	}
-	proto.lastPos = encodedLen
-	return proto.buffer[:encodedLen], nil
+	encodedLen := len(encoded)
+	proto.buffer[encodedLen] = frameDelimiter
+	proto.lastPos = encodedLen + 1
+	return proto.buffer[:proto.lastPos], nil
 }
 
 // Decode decodes given source slice to the raw data
 // It is assumed that the source slice was encoded with COBS encoding
 // It is also assumed that after encoding removal, raw data consist of data + crc check sum
-// If checksum read after decoding is not correct, error will be returned
+// If checksum read after decoding is not correct, ChecksumError will be returned
+// If source is not a valid COBS frame, FramingError will be returned
 func (proto *ProtocolParser) Decode(src []byte) ([]byte, error) {
 	sourceLength := len(src)
 	if len(proto.buffer) < sourceLength {
@@ -70,18 +105,78 @@ func (proto *ProtocolParser) Decode(src []byte) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	if decodedLength < 2 {
-		return nil, fmt.Errorf("decoded message is too short. Decoded length: %v", decodedLength)
+	crcLen := proto.checksum.Size()
+	if decodedLength < crcLen {
+		return nil, fmt.Errorf("%w. Decoded length: %v", ErrMessageTooShort, decodedLength)
 	}
 	msgWithoutCrcLen := decodedLength - crcLen
-	msgWithoutCrc := proto.buffer[:msgWithoutCrcLen]
-	msgCrc := proto.buffer[msgWithoutCrcLen:decodedLength]
-	calculatedCrc := fletcher16(msgWithoutCrc)
-	if !bytes.Equal(msgCrc, calculatedCrc) {
-		return nil, fmt.Errorf("calculated crc %v doesn't match received one %v", calculatedCrc, msgCrc)
+	if err := proto.verifyChecksum(proto.buffer[:msgWithoutCrcLen], proto.buffer[msgWithoutCrcLen:decodedLength]); err != nil {
+		return nil, err
 	}
 	proto.lastPos = decodedLength
-	return msgWithoutCrc, nil
+	return proto.buffer[:msgWithoutCrcLen], nil
+}
+
+// verifyChecksum compares the checksum received with the message to the one calculated over it
+func (proto *ProtocolParser) verifyChecksum(msg, msgCrc []byte) error {
+	calculatedCrc := proto.sumBuffer
+	proto.checksum.Compute(msg, calculatedCrc)
+	if !bytes.Equal(msgCrc, calculatedCrc) {
+		return &ChecksumError{Expected: append([]byte{}, calculatedCrc...), Actual: append([]byte{}, msgCrc...)}
+	}
+	return nil
+}
+
+// AppendEncode encodes given source slice like Encode and appends the result to dst
+// Data is encoded directly into dst, so the internal buffer and the result of the last Encode are not changed
+// Extended dst slice is returned, so the result is owned by the caller
+// If dst has at least MaxEncodedLen(len(src)) free capacity, no memory is allocated
+func (proto *ProtocolParser) AppendEncode(dst, src []byte) ([]byte, error) {
+	dstLen := len(dst)
+	extended := slices.Grow(dst, proto.MaxEncodedLen(len(src)))
+	encodedLen, err := proto.encodeTo(src, extended[dstLen:cap(extended)])
+	if err != nil {
+		return dst, err
+	}
+	return extended[:dstLen+encodedLen], nil
+}
+
+// AppendDecode decodes given source slice like Decode and appends the result to dst
+// Data is decoded directly into dst, so the internal buffer and the result of the last Decode are not changed
+// Extended dst slice is returned, so the result is owned by the caller
+// If dst has at least MaxDecodedLen(len(src)) free capacity, no memory is allocated
+func (proto *ProtocolParser) AppendDecode(dst, src []byte) ([]byte, error) {
+	dstLen := len(dst)
+	maxLen := proto.MaxDecodedLen(len(src))
+	extended := slices.Grow(dst, maxLen)
+	// checksum and the trimmed COBS zero may not fit into dst, the rest of them is decoded to the tail buffer
+	window := extended[dstLen : dstLen+maxLen]
+	decodedLength, err := cobsDecodeSplit(src, window, proto.tailBuffer)
+	if err != nil {
+		return dst, err
+	}
+	crcLen := proto.checksum.Size()
+	if decodedLength < crcLen {
+		return dst, fmt.Errorf("%w. Decoded length: %v", ErrMessageTooShort, decodedLength)
+	}
+	msgWithoutCrcLen := decodedLength - crcLen
+	msgCrc := append(proto.crcBuffer[:0], window[msgWithoutCrcLen:min(decodedLength, maxLen)]...)
+	msgCrc = append(msgCrc, proto.tailBuffer[:max(decodedLength-maxLen, 0)]...)
+	proto.crcBuffer = msgCrc
+	if err := proto.verifyChecksum(window[:msgWithoutCrcLen], msgCrc); err != nil {
+		return dst, err
+	}
+	return extended[:dstLen+msgWithoutCrcLen], nil
+}
+
+// MaxEncodedLen returns the maximum length of encoded data for the payload of given length
+func (proto *ProtocolParser) MaxEncodedLen(payloadLen int) int {
+	return maxEncodedLen(payloadLen, proto.checksum.Size())
+}
+
+// MaxDecodedLen returns the maximum length of payload decoded from the data of given length
+func (proto *ProtocolParser) MaxDecodedLen(encodedLen int) int {
+	return maxDecodedLen(encodedLen, proto.checksum.Size())
 }
 
 // Copy will make a copy of the last encode/decode operation
@@ -91,3 +186,16 @@ func (proto *ProtocolParser) Copy() []byte {
 	copy(newArray, proto.buffer[:proto.lastPos])
 	return newArray
 }
+
+func maxEncodedLen(payloadLen, checksumLen int) int {
+	return cobsGetEncodedBufferSize(payloadLen + checksumLen)
+}
+
+func maxDecodedLen(encodedLen, checksumLen int) int {
+	// COBS adds at least one overhead byte
+	decodedLen := encodedLen - 1 - checksumLen
+	if decodedLen < 0 {
+		return 0
+	}
+	return decodedLen
+}
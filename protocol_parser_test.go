@@ -2,6 +2,7 @@ package binproto
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"math/rand"
 	"testing"
@@ -122,6 +123,210 @@ func TestProtoCrcMismatch(t *testing.T) {
 	}
 }
 
+func TestProtoCrcMismatchReturnsChecksumError(t *testing.T) {
+	//GIVEN
+	src := []byte{1, 1, 1, 0, 0, 1, 5, 12, 44}
+	proto := NewProtocolParser()
+	expectedCrc := make([]byte, fletcher16Len)
+	fletcher16(src, expectedCrc)
+	//WHEN
+	encoded, _ := proto.Encode(src)
+	encodedSave := make([]byte, len(encoded))
+	copy(encodedSave, encoded)
+	encodedSave[len(encodedSave)-1]++
+
+	_, err := proto.Decode(encodedSave)
+	//THEN
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("decoding error %v does not match ErrChecksumMismatch", err)
+	}
+	var checksumErr *ChecksumError
+	if !errors.As(err, &checksumErr) {
+		t.Fatalf("decoding error %v is not a ChecksumError", err)
+	}
+	if !bytes.Equal(checksumErr.Expected, expectedCrc) {
+		t.Errorf("expected crc %v is different than calculated %v", checksumErr.Expected, expectedCrc)
+	}
+	if bytes.Equal(checksumErr.Actual, checksumErr.Expected) {
+		t.Errorf("actual crc %v should be different than expected one", checksumErr.Actual)
+	}
+}
+
+func TestDecodeTooShortMatchesErrMessageTooShort(t *testing.T) {
+	//GIVEN
+	proto := NewProtocolParser()
+	//WHEN
+	_, err := proto.Decode([]byte{1, 1})
+	//THEN
+	if !errors.Is(err, ErrMessageTooShort) {
+		t.Errorf("decoding error %v does not match ErrMessageTooShort", err)
+	}
+}
+
+func TestEncodeFrameAppendsDelimiter(t *testing.T) {
+	//GIVEN
+	src := []byte{1, 1, 1, 0, 0, 1, 5, 12, 44}
+	proto := NewProtocolParser()
+	proto.Encode(src)
+	expectedFrame := append(proto.Copy(), 0)
+	//WHEN
+	frame, err := proto.EncodeFrame(src)
+	//THEN
+	if err != nil {
+		t.Error("frame encoding failed: ", err)
+	}
+	if !bytes.Equal(frame, expectedFrame) {
+		t.Errorf("encoded frame is different than expected. Expected: %v, get: %v", expectedFrame, frame)
+	}
+	if !bytes.Equal(proto.Copy(), expectedFrame) {
+		t.Errorf("copied frame is different than expected. Expected: %v, get: %v", expectedFrame, proto.Copy())
+	}
+}
+
+func TestEncodeDecodeWithEachChecksum(t *testing.T) {
+	//GIVEN
+	src := []byte{1, 1, 1, 0, 0, 1, 5, 12, 44}
+	checksums := []Checksum{NewFletcher16(), NewFletcher32(), NewCRC8(), NewCRC16CCITT(),
+		NewCRC16Modbus(), NewCRC32(), NewCRC32C()}
+	for _, checksum := range checksums {
+		proto := NewProtocolParserWithChecksum(checksum)
+		//WHEN
+		encoded, _ := proto.Encode(src)
+		encodedSave := make([]byte, len(encoded))
+		copy(encodedSave, encoded)
+
+		decoded, err := proto.Decode(encodedSave)
+		//THEN
+		if err != nil {
+			t.Errorf("Decoding with %T checksum failed. Error: %v", checksum, err)
+		}
+		if !bytes.Equal(decoded, src) {
+			t.Errorf("Decoded array %v with %T checksum does not equal to the source %v", decoded, checksum, src)
+		}
+	}
+}
+
+func TestDecodeWithDifferentChecksumFails(t *testing.T) {
+	//GIVEN
+	src := []byte{1, 1, 1, 0, 0, 1, 5, 12, 44}
+	encoder := NewProtocolParserWithChecksum(NewCRC16CCITT())
+	decoder := NewProtocolParserWithChecksum(NewCRC16Modbus())
+	//WHEN
+	encoded, _ := encoder.Encode(src)
+	_, err := decoder.Decode(encoded)
+	//THEN
+	if err == nil {
+		t.Error("decoding with different checksum succeed. This should fail")
+	}
+}
+
+func TestAppendEncodeDecodePositive(t *testing.T) {
+	//GIVEN
+	src := []byte{1, 1, 1, 0, 0, 1, 5, 12, 44}
+	prefix := []byte{9, 9}
+	proto := NewProtocolParser()
+	//WHEN
+	encoded, _ := proto.AppendEncode(prefix, src)
+	decoded, err := proto.AppendDecode(prefix, encoded[len(prefix):])
+	//THEN
+	if err != nil {
+		t.Error("append decoding failed: ", err)
+	}
+	if !bytes.Equal(decoded[:len(prefix)], prefix) {
+		t.Errorf("Destination prefix %v was modified, expected %v", decoded[:len(prefix)], prefix)
+	}
+	if !bytes.Equal(decoded[len(prefix):], src) {
+		t.Errorf("Decoded array %v does not equal to the source %v", decoded[len(prefix):], src)
+	}
+}
+
+func TestAppendDecodeReturnsDestinationOnError(t *testing.T) {
+	//GIVEN
+	dst := []byte{9, 9}
+	proto := NewProtocolParser()
+	//WHEN
+	result, err := proto.AppendDecode(dst, []byte{3, 1})
+	//THEN
+	if err == nil {
+		t.Error("malformed message decoding succeed. This should fail")
+	}
+	if !bytes.Equal(result, dst) {
+		t.Errorf("Destination %v was modified, expected %v", result, dst)
+	}
+}
+
+func TestAppendEncodeDecodeDoNotAllocateWithPresizedBuffers(t *testing.T) {
+	//GIVEN
+	src := []byte{1, 1, 1, 0, 0, 1, 5, 12, 44}
+	proto := NewProtocolParser()
+	encodeBuffer := make([]byte, 0, proto.MaxEncodedLen(len(src)))
+	encoded, _ := proto.AppendEncode(encodeBuffer, src)
+	decodeBuffer := make([]byte, 0, proto.MaxDecodedLen(len(encoded)))
+	//WHEN
+	allocs := testing.AllocsPerRun(100, func() {
+		proto.AppendEncode(encodeBuffer, src)
+		proto.AppendDecode(decodeBuffer, encoded)
+	})
+	//THEN
+	if allocs != 0 {
+		t.Errorf("Append functions allocated %v times, expected no allocations", allocs)
+	}
+}
+
+func TestAppendEncodeDecodeKeepInternalBuffer(t *testing.T) {
+	//GIVEN
+	proto := NewProtocolParser()
+	last, _ := proto.Encode([]byte{7, 7, 7})
+	lastSave := proto.Copy()
+	//WHEN
+	encoded, _ := proto.AppendEncode(nil, []byte{1, 2, 3})
+	proto.AppendDecode(nil, encoded)
+	//THEN
+	if !bytes.Equal(last, lastSave) || !bytes.Equal(proto.Copy(), lastSave) {
+		t.Errorf("Result of the last Encode %v was changed to %v", lastSave, last)
+	}
+}
+
+func TestAppendDecodeLongMessageWithPresizedBuffer(t *testing.T) {
+	//GIVEN
+	proto := NewProtocolParserWithChecksum(NewCRC32())
+	src := make([]byte, 1000)
+	rand.Read(src)
+	encoded, _ := proto.AppendEncode(nil, src)
+	dst := make([]byte, 0, proto.MaxDecodedLen(len(encoded)))
+	//WHEN
+	decoded, err := proto.AppendDecode(dst, encoded)
+	//THEN
+	if err != nil {
+		t.Error("append decoding failed: ", err)
+	}
+	if !bytes.Equal(decoded, src) {
+		t.Error("Decoded array does not equal to the source")
+	}
+	if &decoded[0] != &dst[:1][0] {
+		t.Error("Message was not decoded into the destination buffer")
+	}
+}
+
+func TestMaxEncodedDecodedLen(t *testing.T) {
+	//GIVEN
+	proto := NewProtocolParserWithChecksum(NewCRC32())
+	src := make([]byte, 1000)
+	rand.Read(src)
+	//WHEN
+	encoded, _ := proto.Encode(src)
+	//THEN
+	if len(encoded) > proto.MaxEncodedLen(len(src)) {
+		t.Errorf("Encoded length %v exceeds MaxEncodedLen %v", len(encoded), proto.MaxEncodedLen(len(src)))
+	}
+	if proto.MaxDecodedLen(len(encoded)) < len(src) {
+		t.Errorf("MaxDecodedLen %v is lower than the source length %v", proto.MaxDecodedLen(len(encoded)), len(src))
+	}
+	if proto.MaxDecodedLen(2) != 0 {
+		t.Errorf("MaxDecodedLen for too short data is %v, expected 0", proto.MaxDecodedLen(2))
+	}
+}
+
 func BenchmarkBinProto_Encode(b *testing.B) {
 	src := []byte{1, 1, 1, 0, 0, 1, 5, 12, 44}
 	proto := NewProtocolParser()
@@ -2,26 +2,113 @@ package binproto
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"io"
 	"time"
-
-	"github.com/desertbit/timer"
 )
 
 // ProtocolReadWriter is a helper class to ease i/o operations with encoded data
 // It contains internal protocol decoder which will decode incoming messages
+// If the transport implements SetReadDeadline (net.Conn, os.File), reads are blocking and deadlines are used
+// to stop them. Other transports are read directly and must return when no data is available,
+// unless blocking reads are enabled with SetBlockingReads
 type ProtocolReadWriter struct {
 	decoder EncodeDecoder
+	// sequenced and addressed are found in the chain of wrapped parsers, they are nil if not used
+	sequenced sequencedDecoder
+	addressed addressedDecoder
 
-	retryCount  int
-	retryDelay  time.Duration
+	retryPolicy RetryPolicy
 	readDelay   time.Duration
 	readTimeout time.Duration
-	timeout     *timer.Timer
+	transport   *transportReader
+
+	resync         bool
+	discardedBytes uint64
+	staleResponses uint64
+	ignoredFrames  uint64
+
+	flushQuietPeriod time.Duration
+	flushHook        FlushHook
+	flushBuffer      []byte
 
+	echoCancellation bool
+	echoBuffer       []byte
+
+	requestBuffer []byte
 	readBuffer    bytes.Buffer
 	messageBuffer bytes.Buffer
+	// lastReadTime is the time when the last data was added to the read buffer, tracked if readDelay is set
+	lastReadTime time.Time
+}
+
+// FlushHook is called with the bytes discarded by the input flush
+// Given slice is valid only until the hook returns
+type FlushHook func(flushed []byte)
+
+// receiveMode describes how the incoming frame is awaited and validated
+type receiveMode struct {
+	// waitForData keeps waiting until the deadline when the input stream is empty
+	waitForData bool
+	// matchRequest drops frames which are not responses to the last request, based on sequence number or address
+	matchRequest bool
+}
+
+var (
+	// responseMode is used to read the response to the request
+	responseMode = receiveMode{waitForData: false, matchRequest: true}
+	// continuationMode is used to read the next frame of multi-frame response
+	continuationMode = receiveMode{waitForData: true, matchRequest: true}
+	// unsolicitedMode is used to read frames sent by the device without any request
+	unsolicitedMode = receiveMode{waitForData: true, matchRequest: false}
+)
+
+// sequencedDecoder is implemented by decoders which add sequence numbers to the messages, like SequenceParser
+type sequencedDecoder interface {
+	SequenceEnabled() bool
+	LastEncodedSequence() byte
+	LastDecodedSequence() byte
+}
+
+// addressedDecoder is implemented by decoders which read the addresses of the messages, like AddressParser
+type addressedDecoder interface {
+	Address() byte
+	Destination() byte
+	LastDestination() byte
+	LastSource() byte
+}
+
+// parserWrapper is implemented by parsers which wrap other parser, like SequenceParser and AddressParser
+type parserWrapper interface {
+	Unwrap() EncodeDecoder
+}
+
+// findDecoder returns the first decoder of type T in the chain of wrapped parsers
+func findDecoder[T any](decoder EncodeDecoder) (T, bool) {
+	for decoder != nil {
+		if found, ok := decoder.(T); ok {
+			return found, true
+		}
+		wrapper, ok := decoder.(parserWrapper)
+		if !ok {
+			break
+		}
+		decoder = wrapper.Unwrap()
+	}
+	var notFound T
+	return notFound, false
+}
+
+// inputResetter is implemented by serial ports which can discard their input buffer
+type inputResetter interface {
+	ResetInputBuffer() error
+}
+
+// inputDrainer is implemented by transports which can discard their pending input
+type inputDrainer interface {
+	Drain() error
 }
 
 var (
@@ -33,106 +120,552 @@ var (
 	ErrNoDataRead = errors.New("no data was read from input stream")
 	// ErrTimeout is returned when write/read cycle was unable to finish in given time
 	ErrTimeout = errors.New("write/read operation timed out")
+	// ErrStaleResponse is reported when the response sequence number doesn't match the request one
+	ErrStaleResponse = errors.New("response sequence number doesn't match the request")
+	// ErrNoPendingFrame is returned by ReadPending when there is no complete frame in the receive buffer
+	ErrNoPendingFrame = errors.New("no complete frame in the receive buffer")
+	// ErrInterByteTimeout is returned when the gap between bytes of the response frame exceeds the read delay
+	ErrInterByteTimeout = errors.New("inter-byte timeout exceeded while reading frame")
+	// ErrBusCollision is returned when the local echo of the written data differs from it
+	ErrBusCollision = errors.New("local echo doesn't match written data, bus collision detected")
 )
 
+// NewProtocolReadWriter returns new ProtocolReadWriter which retries failed write/read cycles
+// up to retryCount times, waiting retryDelay between attempts
+// Errors are classified with DefaultRetryable, so closed transport is not retried
+// readTimeout limits the whole response read, while readDelay is the maximum gap allowed
+// between consecutive bytes of the response frame (Modbus-like character timeout). Zero readDelay disables it
 func NewProtocolReadWriter(protocolParser EncodeDecoder, retryCount int, retryDelay, readDelay, readTimeout time.Duration) *ProtocolReadWriter {
-	return &ProtocolReadWriter{protocolParser, retryCount, retryDelay, readDelay,
-		readTimeout, timer.NewTimer(0), bytes.Buffer{}, bytes.Buffer{}}
+	return NewProtocolReadWriterWithPolicy(protocolParser, NewConstantBackoff(retryCount, retryDelay), readDelay, readTimeout)
+}
+
+// NewProtocolReadWriterWithPolicy returns new ProtocolReadWriter which retries failed write/read cycles
+// according to the given retry policy
+func NewProtocolReadWriterWithPolicy(protocolParser EncodeDecoder, retryPolicy RetryPolicy, readDelay, readTimeout time.Duration) *ProtocolReadWriter {
+	sequenced, _ := findDecoder[sequencedDecoder](protocolParser)
+	addressed, _ := findDecoder[addressedDecoder](protocolParser)
+	return &ProtocolReadWriter{decoder: protocolParser, sequenced: sequenced, addressed: addressed, retryPolicy: retryPolicy,
+		readDelay: readDelay, readTimeout: readTimeout, transport: newTransportReader()}
+}
+
+// Transact encodes given payload with the protocol parser, writes it as a frame to the readWriter
+// and reads the decoded response. It works like RetryWriteRead, but takes raw payload instead of encoded frame
+func (p *ProtocolReadWriter) Transact(readWriter io.ReadWriter, payload []byte) ([]byte, error) {
+	return p.TransactContext(context.Background(), readWriter, payload)
+}
+
+// TransactContext works like Transact, but stops as soon as given context is done
+func (p *ProtocolReadWriter) TransactContext(ctx context.Context, readWriter io.ReadWriter, payload []byte) ([]byte, error) {
+	request, err := p.encodeRequest(payload)
+	if err != nil {
+		return nil, err
+	}
+	return p.RetryWriteReadContext(ctx, readWriter, request)
+}
+
+// encodeRequest encodes given payload to the request buffer and returns the zero ended frame
+func (p *ProtocolReadWriter) encodeRequest(payload []byte) ([]byte, error) {
+	frame, err := encodeFrame(p.decoder, payload, &p.requestBuffer)
+	if err != nil {
+		return nil, err
+	}
+	// parser reuses its buffer to decode the response, so the request must be copied
+	p.requestBuffer = append(p.requestBuffer[:0], frame...)
+	return p.requestBuffer, nil
 }
 
+// RetryWriteRead writes given zero ended source to the readWriter and reads the response frame
+// Source must be already encoded, use Transact to send raw payload
+// The write/read cycle is repeated according to the retry policy if it fails
+// If all cycles fail, RetryError with errors of all attempts is returned
 func (p *ProtocolReadWriter) RetryWriteRead(readWriter io.ReadWriter, src []byte) ([]byte, error) {
+	return p.RetryWriteReadContext(context.Background(), readWriter, src)
+}
+
+// RetryWriteReadContext works like RetryWriteRead, but stops as soon as given context is done
+// Both the read loop and the sleep between retries are interrupted by the context
+// If context is done, its error is returned wrapped together with the last attempt error
+func (p *ProtocolReadWriter) RetryWriteReadContext(ctx context.Context, readWriter io.ReadWriter, src []byte) ([]byte, error) {
 	sourceLength := len(src)
 	if src[sourceLength-1] != 0 {
 		return nil, ErrSourceNotEndsWithZero
 	}
 
 	p.messageBuffer.Reset()
-	p.readBuffer.Reset()
 
-	err := Retry(p.retryCount, p.retryDelay, func() error {
-		p.timeout.Reset(p.readTimeout)
+	err := RetryWithPolicy(ctx, p.retryPolicy, func() error {
+		// local echo and the response share the same read timeout
+		responseDeadline := time.Now().Add(p.readTimeout)
+		if err := p.writeRequest(ctx, readWriter, src, responseDeadline); err != nil {
+			return err
+		}
+		return p.receiveMessage(ctx, readWriter, responseDeadline, p.responseMode())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return p.messageBuffer.Bytes(), nil
+}
+
+// writeRequest flushes the input, if enabled, and writes the whole request to the transport
+// If echo cancellation is enabled, the local echo of the request is consumed before given deadline
+func (p *ProtocolReadWriter) writeRequest(ctx context.Context, readWriter io.ReadWriter, src []byte, echoDeadline time.Time) error {
+	if p.flushQuietPeriod > 0 {
+		if err := p.flushInput(ctx, readWriter); err != nil {
+			return err
+		}
+	}
+	return p.write(ctx, readWriter, src, echoDeadline)
+}
+
+// write writes the whole source to the writer and consumes its local echo, if enabled and possible
+func (p *ProtocolReadWriter) write(ctx context.Context, writer io.Writer, src []byte, echoDeadline time.Time) error {
+	echoStart := p.readBuffer.Len()
+	written, err := writer.Write(src)
+	if err != nil {
+		return err
+	}
+	if written != len(src) {
+		return ErrWrittenLengthDoesNotMatch
+	}
+	if reader, ok := writer.(io.Reader); ok && p.echoCancellation {
+		return p.consumeEcho(ctx, reader, src, echoStart, echoDeadline)
+	}
+	return nil
+}
+
+// responseMode returns the mode of reading the response to the request
+// Local echo is received right after the write, but the response follows it only after the device handles
+// the request. So when echo cancellation is enabled, the response is awaited until the deadline
+func (p *ProtocolReadWriter) responseMode() receiveMode {
+	if p.echoCancellation {
+		return continuationMode
+	}
+	return responseMode
+}
+
+// WriteFrame encodes given payload with the protocol parser and writes it as a frame to the writer
+// No response is expected, so it can be used to broadcast commands. Write is not retried
+// Local echo is consumed only if the writer is also a reader. It must arrive within the read timeout
+func (p *ProtocolReadWriter) WriteFrame(writer io.Writer, payload []byte) error {
+	frame, err := encodeFrame(p.decoder, payload, &p.requestBuffer)
+	if err != nil {
+		return err
+	}
+	return p.write(context.Background(), writer, frame, time.Now().Add(p.readTimeout))
+}
+
+// ReadFrame waits for the frame sent by the device without any request, like an event notification
+// Frames already waiting in the receive buffer are returned first
+// ErrTimeout is returned if no complete frame is received in the given time
+// Returned slice is valid until the next ProtocolReadWriter call
+func (p *ProtocolReadWriter) ReadFrame(reader io.Reader, timeout time.Duration) ([]byte, error) {
+	return p.ReadFrameContext(context.Background(), reader, timeout)
+}
 
-		written, err := readWriter.Write(src)
+// ReadFrameContext works like ReadFrame, but stops as soon as given context is done
+func (p *ProtocolReadWriter) ReadFrameContext(ctx context.Context, reader io.Reader, timeout time.Duration) ([]byte, error) {
+	p.messageBuffer.Reset()
+	if err := p.receiveMessage(ctx, reader, time.Now().Add(timeout), unsolicitedMode); err != nil {
+		return nil, err
+	}
+	return p.messageBuffer.Bytes(), nil
+}
+
+// ReadPending decodes the next complete frame which was already received, but not consumed by the transaction
+// It may be a frame which arrived after the response in the same read, like an unsolicited notification
+// ErrNoPendingFrame is returned if there is no complete frame in the receive buffer
+// Returned slice is valid until the next ProtocolReadWriter call
+func (p *ProtocolReadWriter) ReadPending() ([]byte, error) {
+	zeroIndex := bytes.IndexByte(p.readBuffer.Bytes(), frameDelimiter)
+	if zeroIndex < 0 {
+		return nil, ErrNoPendingFrame
+	}
+	p.messageBuffer.Reset()
+	if err := p.decodeMessage(p.readBuffer.Next(zeroIndex + 1)[:zeroIndex]); err != nil {
+		return nil, err
+	}
+	return p.messageBuffer.Bytes(), nil
+}
+
+// Pending returns the number of received bytes which were not consumed yet
+// They will be used by the next transaction or ReadPending call. Incomplete frame is dropped
+// by the next transaction, if it was received earlier than the inter-byte timeout ago
+func (p *ProtocolReadWriter) Pending() int {
+	return p.readBuffer.Len()
+}
+
+// receiveMessage receives the next frame and decodes it to the message buffer
+// In resync mode frames which can't be decoded are dropped and the next frame is awaited until the deadline
+// If the mode requires it and the decoder uses sequence numbers, responses to other requests are dropped as stale
+// Sequence numbers and addresses are checked also if their parsers are wrapped by other ones
+func (p *ProtocolReadWriter) receiveMessage(ctx context.Context, reader io.Reader, responseDeadline time.Time, mode receiveMode) error {
+	var dropErr error
+	for {
+		message, err := p.receiveFrame(ctx, reader, responseDeadline, mode)
 		if err != nil {
+			if dropErr != nil {
+				return fmt.Errorf("%w, last dropped frame: %w", err, dropErr)
+			}
 			return err
 		}
-		if written != sourceLength {
-			return ErrWrittenLengthDoesNotMatch
+		// skip 0 sign runs, which are left after line noise
+		if p.resync && len(message) == 0 {
+			p.discardedBytes++
+			continue
+		}
+		messageStart := p.messageBuffer.Len()
+		dropErr = p.decodeMessage(message)
+		if dropErr != nil {
+			if !p.resync {
+				return dropErr
+			}
+			p.discardedBytes += uint64(len(message) + 1)
+			continue
 		}
+		if p.addressed != nil {
+			if err := checkAddress(p.addressed, mode); err != nil {
+				p.messageBuffer.Truncate(messageStart)
+				p.ignoredFrames++
+				dropErr = err
+				continue
+			}
+		}
+		if p.sequenced != nil && mode.matchRequest && p.sequenced.SequenceEnabled() &&
+			p.sequenced.LastDecodedSequence() != p.sequenced.LastEncodedSequence() {
+			p.messageBuffer.Truncate(messageStart)
+			p.staleResponses++
+			dropErr = fmt.Errorf("%w. Expected: %v, get: %v", ErrStaleResponse,
+				p.sequenced.LastEncodedSequence(), p.sequenced.LastDecodedSequence())
+			continue
+		}
+		return nil
+	}
+}
 
-		stopRead := false
-		timeout := false
-		lastReadBytes := int64(0)
-		zeroIndex := 0
-		for stopRead == false {
-			select {
-			default:
-				readLen, inErr := p.readBuffer.ReadFrom(readWriter)
-				if inErr != nil {
-					return inErr
-				}
-				// no data in input stream -> stop reader loop
-				if readLen == 0 {
-					stopRead = true
-					break
-				}
-				// data contains 0 sign, which means we get whole message -> stop reader loop
-				lastReadBytes += readLen
-				for i, value := range p.readBuffer.Bytes()[:lastReadBytes] {
-					if value == 0 {
-						zeroIndex = i
-						stopRead = true
-						break
-					}
-				}
-			case <-p.timeout.C:
-				stopRead = true
-				timeout = true
+// receiveFrame reads data from the transport until the receive buffer contains a complete frame
+// Frame is removed from the buffer and returned without the ending 0 sign. Bytes received after it are kept
+// On error the incomplete frame is dropped from the buffer
+// If the mode doesn't wait for data, ErrNoDataRead is returned when there is no data in the input stream
+func (p *ProtocolReadWriter) receiveFrame(ctx context.Context, reader io.Reader, responseDeadline time.Time, mode receiveMode) ([]byte, error) {
+	// bytes left from the previous transaction might already contain the frame
+	if zeroIndex := bytes.IndexByte(p.readBuffer.Bytes(), frameDelimiter); zeroIndex >= 0 {
+		return p.readBuffer.Next(zeroIndex + 1)[:zeroIndex], nil
+	}
+
+	// incomplete frame left by the previous transaction can't be continued after the inter-byte timeout
+	if p.readBuffer.Len() > 0 && p.readDelay > 0 && time.Since(p.lastReadTime) > p.readDelay {
+		p.dropReadBuffer()
+	}
+
+	for {
+		// after first byte was received, frame must be completed before inter-byte timeout
+		readDeadline, readTimeoutErr := responseDeadline, ErrTimeout
+		if p.readBuffer.Len() > 0 && p.readDelay > 0 {
+			if interByteDeadline := p.lastReadTime.Add(p.readDelay); interByteDeadline.Before(readDeadline) {
+				readDeadline, readTimeoutErr = interByteDeadline, ErrInterByteTimeout
+			}
+		}
+		// incomplete frame must not be extended forever by the transport which is never empty
+		if p.readBuffer.Len() > 0 && !time.Now().Before(readDeadline) {
+			p.dropReadBuffer()
+			return nil, readTimeoutErr
+		}
+
+		chunk, inErr := p.transport.read(ctx, reader, readDeadline)
+		if inErr == errReadDeadline {
+			inErr = readTimeoutErr
+		}
+		if inErr != nil && inErr != io.EOF {
+			p.dropReadBuffer()
+			return nil, inErr
+		}
+		if len(chunk) == 0 {
+			// no data in input stream -> repeat write/read cycle
+			if p.readBuffer.Len() == 0 && !mode.waitForData {
+				return nil, ErrNoDataRead
+			}
+			// frame is not complete and source has no data at the moment -> wait for the rest of it
+			if err := p.transport.wait(ctx, emptyReadPollInterval); err != nil {
+				p.dropReadBuffer()
+				return nil, err
+			}
+			if !time.Now().Before(readDeadline) {
+				p.dropReadBuffer()
+				return nil, readTimeoutErr
+			}
+			continue
+		}
+		if p.readDelay > 0 {
+			// direct read of the transport may return after the deadline, when the gap was already exceeded
+			p.lastReadTime = time.Now()
+			if p.lastReadTime.After(readDeadline) {
+				p.dropReadBuffer()
+				return nil, readTimeoutErr
+			}
+		}
+
+		bufferedLen := p.readBuffer.Len()
+		p.readBuffer.Write(chunk)
+		// data contains 0 sign, which means we get whole message -> stop reader loop
+		if i := bytes.IndexByte(chunk, frameDelimiter); i >= 0 {
+			zeroIndex := bufferedLen + i
+			return p.readBuffer.Next(zeroIndex + 1)[:zeroIndex], nil
+		}
+	}
+}
+
+// dropReadBuffer drops the incomplete frame from the receive buffer, in resync mode it is counted as discarded
+func (p *ProtocolReadWriter) dropReadBuffer() {
+	if p.resync {
+		p.discardedBytes += uint64(p.readBuffer.Len())
+	}
+	p.readBuffer.Reset()
+}
+
+// checkAddress verifies that the decoded message is addressed to this node
+// Responses must also come from the device the request was sent to
+func checkAddress(addressed addressedDecoder, mode receiveMode) error {
+	destination, source := addressed.LastDestination(), addressed.LastSource()
+	if destination != addressed.Address() && (destination != BroadcastAddress || mode.matchRequest) {
+		return fmt.Errorf("%w. Expected destination: %v, get: %v", ErrAddressMismatch, addressed.Address(), destination)
+	}
+	if mode.matchRequest && source != addressed.Destination() {
+		return fmt.Errorf("%w. Expected source: %v, get: %v", ErrAddressMismatch, addressed.Destination(), source)
+	}
+	return nil
+}
+
+// decodeMessage decodes given message and writes the result to the message buffer
+func (p *ProtocolReadWriter) decodeMessage(message []byte) error {
+	decodedMessage, err := p.decoder.Decode(message)
+	if err != nil {
+		return fmt.Errorf("response decoding failed: %w", err)
+	}
+	_, err = p.messageBuffer.Write(decodedMessage)
+	return err
+}
+
+// SetResync enables or disables the resynchronisation mode
+// In resync mode, response frame which can't be decoded is dropped together with its delimiter,
+// and the next frame received before the read timeout is used instead. Empty frames (0 sign runs) are skipped.
+// Number of dropped bytes, including incomplete frames dropped on timeout or read error, is reported by DiscardedBytes
+func (p *ProtocolReadWriter) SetResync(enabled bool) {
+	p.resync = enabled
+}
+
+// DiscardedBytes returns the total number of bytes dropped in resync mode
+func (p *ProtocolReadWriter) DiscardedBytes() uint64 {
+	return p.discardedBytes
+}
+
+// SetFlush enables discarding of stale input before each write, so late response to the previous attempt
+// is not taken as the response to the current one. Bytes left in the receive buffer are discarded as well
+// If the transport implements ResetInputBuffer() error or Drain() error, it is called to discard its input,
+// otherwise the transport is read until no data arrives for the quietPeriod (but not longer than read timeout)
+// Discarded bytes are reported to the hook, if it is not nil. Zero quietPeriod disables flushing
+func (p *ProtocolReadWriter) SetFlush(quietPeriod time.Duration, hook FlushHook) {
+	p.flushQuietPeriod = quietPeriod
+	p.flushHook = hook
+}
+
+// flushInput discards the receive buffer and all the data which is waiting in the transport
+func (p *ProtocolReadWriter) flushInput(ctx context.Context, reader io.Reader) error {
+	p.flushBuffer = append(p.flushBuffer[:0], p.readBuffer.Bytes()...)
+	p.readBuffer.Reset()
+
+	if resetter, ok := reader.(inputResetter); ok {
+		if err := resetter.ResetInputBuffer(); err != nil {
+			return err
+		}
+	} else if drainer, ok := reader.(inputDrainer); ok {
+		if err := drainer.Drain(); err != nil {
+			return err
+		}
+	} else {
+		// input is flushed until the line stays quiet for the whole quiet period
+		flushDeadline := time.Now().Add(p.readTimeout)
+		quietDeadline := time.Now().Add(p.flushQuietPeriod)
+		for {
+			readDeadline := quietDeadline
+			if readDeadline.After(flushDeadline) {
+				readDeadline = flushDeadline
+			}
+			if !time.Now().Before(readDeadline) {
 				break
 			}
+			chunk, err := p.transport.read(ctx, reader, readDeadline)
+			if err == errReadDeadline {
+				break
+			}
+			p.flushBuffer = append(p.flushBuffer, chunk...)
+			if err != nil && err != io.EOF {
+				return err
+			}
+			if len(chunk) == 0 {
+				if err := p.transport.wait(ctx, emptyReadPollInterval); err != nil {
+					return err
+				}
+				continue
+			}
+			quietDeadline = time.Now().Add(p.flushQuietPeriod)
 		}
+	}
+
+	if len(p.flushBuffer) > 0 && p.flushHook != nil {
+		p.flushHook(p.flushBuffer)
+	}
+	return nil
+}
 
-		// timer was fired, we might not receive data at all or do not receive the ending 0 sign
-		if timeout == true {
-			return ErrTimeout
+// SetEchoCancellation enables or disables the local echo cancellation
+// Half-duplex adapters, like many USB-RS485 converters, receive every transmitted byte back
+// When enabled, written data is expected back before the response. It is verified and removed from the input,
+// ErrBusCollision is returned if it differs from the written data
+// Echo is awaited within the read timeout of the transaction, so it doesn't extend the time given for the response
+// Response which follows the echo is awaited until the read timeout, even if the transport has no data at the moment
+func (p *ProtocolReadWriter) SetEchoCancellation(enabled bool) {
+	p.echoCancellation = enabled
+}
+
+// consumeEcho reads the local echo of the written data and removes it from the receive buffer
+// Echo starts at given offset, as bytes received before the write are kept in the buffer
+func (p *ProtocolReadWriter) consumeEcho(ctx context.Context, reader io.Reader, src []byte, echoStart int, echoDeadline time.Time) error {
+	for p.readBuffer.Len() < echoStart+len(src) {
+		if !time.Now().Before(echoDeadline) {
+			received := p.readBuffer.Len() - echoStart
+			p.readBuffer.Reset()
+			return fmt.Errorf("%w. Local echo received: %v of %v bytes", ErrTimeout, received, len(src))
 		}
-		// no data was read from input -> repeat write/read cycle
-		if lastReadBytes == 0 {
-			return ErrNoDataRead
+		chunk, err := p.transport.read(ctx, reader, echoDeadline)
+		if err == errReadDeadline {
+			continue
 		}
-		// get message without ending 0 sign
-		message := p.readBuffer.Bytes()[:zeroIndex]
-		decodedMessage, err := p.decoder.Decode(message)
-		if err != nil {
+		if err != nil && err != io.EOF {
+			p.readBuffer.Reset()
 			return err
 		}
-		_, err = p.messageBuffer.Write(decodedMessage)
-		if err != nil {
+		if len(chunk) == 0 {
+			if err := p.transport.wait(ctx, emptyReadPollInterval); err != nil {
+				p.readBuffer.Reset()
+				return err
+			}
+			continue
+		}
+		bufferedLen := p.readBuffer.Len()
+		p.readBuffer.Write(chunk)
+		if p.readDelay > 0 {
+			p.lastReadTime = time.Now()
+		}
+		// stop early, the rest of the echo won't fix the collision
+		if err := compareEcho(src, p.readBuffer.Bytes()[echoStart:], bufferedLen-echoStart); err != nil {
+			p.readBuffer.Reset()
 			return err
 		}
-		return nil
-	})
-	if err != nil {
-		return nil, err
 	}
 
-	return p.messageBuffer.Bytes(), nil
+	// remove the echo, keeping bytes received before and after it
+	received := p.readBuffer.Bytes()
+	p.echoBuffer = append(p.echoBuffer[:0], received[echoStart+len(src):]...)
+	p.readBuffer.Truncate(echoStart)
+	p.readBuffer.Write(p.echoBuffer)
+	return nil
+}
+
+// compareEcho compares the received echo with the written data, starting from given offset
+func compareEcho(src, echo []byte, offset int) error {
+	if offset < 0 {
+		offset = 0
+	}
+	for i := offset; i < len(echo) && i < len(src); i++ {
+		if echo[i] != src[i] {
+			return fmt.Errorf("%w. Offset: %v, expected: %v, get: %v", ErrBusCollision, i, src[i], echo[i])
+		}
+	}
+	return nil
+}
+
+// StaleResponses returns the number of responses dropped because of sequence number mismatch
+// Sequence numbers are checked only if the protocol parser is, or wraps, the SequenceParser with enabled header
+func (p *ProtocolReadWriter) StaleResponses() uint64 {
+	return p.staleResponses
+}
+
+// IgnoredFrames returns the number of frames dropped because they were addressed to other devices
+// or came from a device other than the one the request was sent to
+func (p *ProtocolReadWriter) IgnoredFrames() uint64 {
+	return p.ignoredFrames
+}
+
+// SetBlockingReads enables reading transports without read deadline support, whose Read blocks until data arrives,
+// like io.Pipe. Such reads are done on the helper goroutine, so they can be interrupted by the timeout or context.
+// It adds the goroutine handoff to each read, so it should be left disabled for transports which don't block
+func (p *ProtocolReadWriter) SetBlockingReads(enabled bool) {
+	p.transport.blocking = enabled
+}
+
+// Close stops the helper goroutine used for blocking reads from transports without read deadline support
+// Calling it is optional, as the goroutine exits on its own when it is idle
+// It doesn't close the transport itself. ProtocolReadWriter can't be used after Close
+func (p *ProtocolReadWriter) Close() error {
+	p.transport.close()
+	return nil
 }
 
 // Retry will try to run callback function
 // If function fails with any error, execution will be retried after given sleep time
-// If all tries will fail, the last error returned from callback will be returned
+// If all tries will fail, RetryError containing all callback errors will be returned
 func Retry(attempts int, sleep time.Duration, callback func() error) error {
-	var lastErr error
+	return RetryContext(context.Background(), attempts, sleep, callback)
+}
+
+// RetryContext works like Retry, but stops as soon as given context is done
+// Sleep between attempts is interrupted when the context is cancelled or its deadline is exceeded
+// In such case context error is returned, wrapped together with the last callback error
+func RetryContext(ctx context.Context, attempts int, sleep time.Duration, callback func() error) error {
+	return RetryWithPolicy(ctx, &ConstantBackoff{Attempts: attempts, Delay: sleep, Classifier: RetryAll}, callback)
+}
+
+// RetryWithPolicy will try to run callback function until it succeeds, according to the given policy
+// Execution is not retried if the policy classifies callback error as not retryable
+// or when the maximum number of attempts is reached. In both cases RetryError with all attempts is returned
+// If given context is done, its error is returned wrapped together with the RetryError
+func RetryWithPolicy(ctx context.Context, policy RetryPolicy, callback func() error) error {
+	var attempts []Attempt
+	var sleep time.Duration
 	for i := 0; ; i++ {
-		lastErr = callback()
-		if lastErr == nil {
+		if ctx.Err() != nil {
+			return wrapContextError(ctx.Err(), attempts)
+		}
+		start := time.Now()
+		err := callback()
+		if err == nil {
 			return nil
 		}
-		if i >= (attempts - 1) {
+		if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+			return wrapContextError(ctx.Err(), attempts)
+		}
+		attempts = append(attempts, Attempt{Err: err, Start: start, Duration: time.Since(start)})
+		if i >= (policy.MaxAttempts()-1) || !policy.Retryable(err) {
 			break
 		}
-		time.Sleep(sleep)
+		sleep = policy.NextDelay(i+1, sleep)
+		sleepTimer := time.NewTimer(sleep)
+		select {
+		case <-sleepTimer.C:
+		case <-ctx.Done():
+			sleepTimer.Stop()
+			return wrapContextError(ctx.Err(), attempts)
+		}
+	}
+	return &RetryError{Attempts: attempts}
+}
+
+// wrapContextError joins context error with the failed attempts, so all of them can be matched with errors.Is
+func wrapContextError(ctxErr error, attempts []Attempt) error {
+	if len(attempts) == 0 {
+		return ctxErr
 	}
-	return lastErr
+	return fmt.Errorf("%w: %w", ctxErr, &RetryError{Attempts: attempts})
 }
@@ -0,0 +1,157 @@
+package binproto
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"time"
+)
+
+// RetryPolicy decides whether failed operation should be retried and how long to wait before the next attempt
+// Policies must not keep any state between calls, so single instance can be shared
+type RetryPolicy interface {
+	// MaxAttempts returns the maximum number of attempts, including the first one
+	MaxAttempts() int
+	// NextDelay returns the sleep time before the next attempt
+	// failedAttempts is the number of already failed attempts, previous is the last returned delay
+	NextDelay(failedAttempts int, previous time.Duration) time.Duration
+	// Retryable reports whether the operation which failed with given error can be retried
+	Retryable(err error) bool
+}
+
+// ErrorClassifier reports whether the operation which failed with given error can be retried
+type ErrorClassifier func(err error) bool
+
+// DefaultRetryable is the ErrorClassifier used by built-in policies when no other one is given
+// Timeouts and checksum errors are retryable, closed transport and context errors are fatal
+// All other errors are treated as retryable
+func DefaultRetryable(err error) bool {
+	switch {
+	case errors.Is(err, ErrTimeout), errors.Is(err, ErrChecksumMismatch):
+		return true
+	case errors.Is(err, io.ErrClosedPipe), errors.Is(err, os.ErrClosed),
+		errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	}
+	return true
+}
+
+// RetryAll is the ErrorClassifier which treats every error as retryable
+func RetryAll(err error) bool {
+	return true
+}
+
+func classify(classifier ErrorClassifier, err error) bool {
+	if classifier == nil {
+		return DefaultRetryable(err)
+	}
+	return classifier(err)
+}
+
+// ConstantBackoff waits the same Delay between each attempt
+type ConstantBackoff struct {
+	Attempts   int
+	Delay      time.Duration
+	Classifier ErrorClassifier
+}
+
+// NewConstantBackoff returns new ConstantBackoff policy which uses DefaultRetryable classifier
+func NewConstantBackoff(attempts int, delay time.Duration) *ConstantBackoff {
+	return &ConstantBackoff{Attempts: attempts, Delay: delay}
+}
+
+// MaxAttempts returns the maximum number of attempts
+func (b *ConstantBackoff) MaxAttempts() int {
+	return b.Attempts
+}
+
+// NextDelay always returns the constant Delay
+func (b *ConstantBackoff) NextDelay(failedAttempts int, previous time.Duration) time.Duration {
+	return b.Delay
+}
+
+// Retryable classifies given error with the policy Classifier
+func (b *ConstantBackoff) Retryable(err error) bool {
+	return classify(b.Classifier, err)
+}
+
+// ExponentialBackoff doubles the delay after each failed attempt, starting from Initial, up to Max
+// Zero Max means that the delay is not capped
+type ExponentialBackoff struct {
+	Attempts   int
+	Initial    time.Duration
+	Max        time.Duration
+	Classifier ErrorClassifier
+}
+
+// NewExponentialBackoff returns new ExponentialBackoff policy which uses DefaultRetryable classifier
+func NewExponentialBackoff(attempts int, initial, max time.Duration) *ExponentialBackoff {
+	return &ExponentialBackoff{Attempts: attempts, Initial: initial, Max: max}
+}
+
+// MaxAttempts returns the maximum number of attempts
+func (b *ExponentialBackoff) MaxAttempts() int {
+	return b.Attempts
+}
+
+// NextDelay returns Initial * 2^(failedAttempts-1), capped at Max
+func (b *ExponentialBackoff) NextDelay(failedAttempts int, previous time.Duration) time.Duration {
+	delay := b.Initial
+	for i := 1; i < failedAttempts; i++ {
+		if (b.Max > 0 && delay >= b.Max) || delay > math.MaxInt64/2 {
+			break
+		}
+		delay *= 2
+	}
+	if b.Max > 0 && delay > b.Max {
+		return b.Max
+	}
+	return delay
+}
+
+// Retryable classifies given error with the policy Classifier
+func (b *ExponentialBackoff) Retryable(err error) bool {
+	return classify(b.Classifier, err)
+}
+
+// DecorrelatedJitter picks random delay between Base and three times the previous delay, capped at Max
+// It spreads the retries of many clients talking over the same medium
+type DecorrelatedJitter struct {
+	Attempts   int
+	Base       time.Duration
+	Max        time.Duration
+	Classifier ErrorClassifier
+}
+
+// NewDecorrelatedJitter returns new DecorrelatedJitter policy which uses DefaultRetryable classifier
+func NewDecorrelatedJitter(attempts int, base, max time.Duration) *DecorrelatedJitter {
+	return &DecorrelatedJitter{Attempts: attempts, Base: base, Max: max}
+}
+
+// MaxAttempts returns the maximum number of attempts
+func (b *DecorrelatedJitter) MaxAttempts() int {
+	return b.Attempts
+}
+
+// NextDelay returns random delay in range [Base, 3 * previous), capped at Max
+func (b *DecorrelatedJitter) NextDelay(failedAttempts int, previous time.Duration) time.Duration {
+	if previous < b.Base {
+		previous = b.Base
+	}
+	delay := b.Base
+	if spread := 3*previous - b.Base; spread > 0 {
+		delay += time.Duration(rand.Int63n(int64(spread)))
+	}
+	if b.Max > 0 && delay > b.Max {
+		return b.Max
+	}
+	return delay
+}
+
+// Retryable classifies given error with the policy Classifier
+func (b *DecorrelatedJitter) Retryable(err error) bool {
+	return classify(b.Classifier, err)
+}
@@ -0,0 +1,105 @@
+package binproto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultRetryableClassification(t *testing.T) {
+	assert.True(t, DefaultRetryable(ErrTimeout))
+	assert.True(t, DefaultRetryable(fmt.Errorf("response decoding failed: %w", &ChecksumError{})))
+	assert.True(t, DefaultRetryable(errors.New("write failed")))
+	assert.False(t, DefaultRetryable(io.ErrClosedPipe))
+	assert.False(t, DefaultRetryable(context.Canceled))
+}
+
+func TestConstantBackoffDelay(t *testing.T) {
+	policy := NewConstantBackoff(3, 5*time.Millisecond)
+	assert.Equal(t, 3, policy.MaxAttempts())
+	assert.Equal(t, 5*time.Millisecond, policy.NextDelay(1, 0))
+	assert.Equal(t, 5*time.Millisecond, policy.NextDelay(2, 5*time.Millisecond))
+}
+
+func TestExponentialBackoffDelayIsDoubledAndCapped(t *testing.T) {
+	policy := NewExponentialBackoff(10, 10*time.Millisecond, 50*time.Millisecond)
+	expectedDelays := []time.Duration{10, 20, 40, 50, 50}
+	for i, expected := range expectedDelays {
+		assert.Equal(t, expected*time.Millisecond, policy.NextDelay(i+1, 0))
+	}
+}
+
+func TestExponentialBackoffDelayDoesNotOverflowWithoutCap(t *testing.T) {
+	policy := NewExponentialBackoff(100, time.Second, 0)
+	assert.True(t, policy.NextDelay(100, 0) > 0)
+}
+
+func TestDecorrelatedJitterDelayStaysInRange(t *testing.T) {
+	base := 10 * time.Millisecond
+	max := 100 * time.Millisecond
+	policy := NewDecorrelatedJitter(10, base, max)
+	previous := time.Duration(0)
+	for i := 1; i < 100; i++ {
+		delay := policy.NextDelay(i, previous)
+		assert.True(t, delay >= base, "delay %v is lower than base %v", delay, base)
+		assert.True(t, delay <= max, "delay %v is higher than max %v", delay, max)
+		assert.True(t, delay <= 3*previous || previous < base, "delay %v is higher than 3 * %v", delay, previous)
+		previous = delay
+	}
+}
+
+func TestRetryWithPolicyStopsOnFatalError(t *testing.T) {
+	calls := 0
+	err := RetryWithPolicy(context.Background(), NewConstantBackoff(5, 0), func() error {
+		calls++
+		return io.ErrClosedPipe
+	})
+	assert.Equal(t, 1, calls)
+	assert.ErrorIs(t, err, io.ErrClosedPipe)
+}
+
+func TestRetryWithPolicyUsesCustomClassifier(t *testing.T) {
+	calls := 0
+	policy := &ConstantBackoff{Attempts: 5, Classifier: func(err error) bool {
+		return !errors.Is(err, ErrTimeout)
+	}}
+	err := RetryWithPolicy(context.Background(), policy, func() error {
+		calls++
+		if calls < 3 {
+			return ErrNoDataRead
+		}
+		return ErrTimeout
+	})
+	assert.Equal(t, 3, calls)
+	assert.ErrorIs(t, err, ErrTimeout)
+}
+
+func TestRetryWithPolicyPassesPreviousDelay(t *testing.T) {
+	policy := &delayRecorderPolicy{}
+	RetryWithPolicy(context.Background(), policy, func() error {
+		return ErrTimeout
+	})
+	assert.Equal(t, []time.Duration{0, time.Microsecond}, policy.previous)
+}
+
+type delayRecorderPolicy struct {
+	previous []time.Duration
+}
+
+func (p *delayRecorderPolicy) MaxAttempts() int {
+	return 3
+}
+
+func (p *delayRecorderPolicy) NextDelay(failedAttempts int, previous time.Duration) time.Duration {
+	p.previous = append(p.previous, previous)
+	return time.Duration(failedAttempts) * time.Microsecond
+}
+
+func (p *delayRecorderPolicy) Retryable(err error) bool {
+	return true
+}
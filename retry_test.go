@@ -1,8 +1,14 @@
 package binproto
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 	"time"
 
@@ -52,6 +58,56 @@ func TestRetryReturnsError(t *testing.T) {
 	}
 }
 
+func TestRetryContextStopsSleepWhenCancelled(t *testing.T) {
+	attempts := 5
+	delay := 10 * time.Second
+	callbackErr := errors.New("internal error")
+	calls := 0
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := RetryContext(ctx, attempts, delay, func() error {
+		calls++
+		return callbackErr
+	})
+	assert.True(t, time.Since(start) < delay, "retry sleep was not interrupted")
+	assert.Equal(t, 1, calls)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.True(t, errors.Is(err, callbackErr))
+}
+
+func TestRetryContextDoesNotCallCallbackWithDoneContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls := 0
+	err := RetryContext(ctx, 3, 0, func() error {
+		calls++
+		return nil
+	})
+	assert.Equal(t, 0, calls)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestRetryReturnsErrorOfEachAttempt(t *testing.T) {
+	attempts := 3
+	errs := []error{&ChecksumError{}, &ChecksumError{}, ErrTimeout}
+	calls := 0
+	err := Retry(attempts, 0, func() error {
+		calls++
+		return errs[calls-1]
+	})
+	var retryErr *RetryError
+	assert.True(t, errors.As(err, &retryErr))
+	assert.Equal(t, attempts, len(retryErr.Attempts))
+	for i, attempt := range retryErr.Attempts {
+		assert.Equal(t, errs[i], attempt.Err)
+		assert.False(t, attempt.Start.IsZero())
+	}
+	assert.Equal(t, ErrTimeout, retryErr.Last())
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+	assert.ErrorIs(t, err, ErrTimeout)
+}
+
 func TestWriteReadShouldSucceed(t *testing.T) {
 	// GIVEN
 	message := []byte("hello")
@@ -141,7 +197,7 @@ func TestWriteReadShouldFailIfWriteFailsWithError(t *testing.T) {
 	// THEN
 	readWriterMock.AssertNumberOfCalls(t, "Write", expectedRetryCount)
 	assert.NotNil(t, err)
-	assert.Equal(t, writeError, err)
+	assert.ErrorIs(t, err, writeError)
 }
 
 func TestWriteReadShouldFailIfNumberOfWrittenBytesIsDifferentThanSrcLength(t *testing.T) {
@@ -170,7 +226,7 @@ func TestWriteReadShouldFailIfNumberOfWrittenBytesIsDifferentThanSrcLength(t *te
 	// THEN
 	readWriterMock.AssertNumberOfCalls(t, "Write", expectedRetryCount)
 	assert.NotNil(t, err)
-	assert.Equal(t, ErrWrittenLengthDoesNotMatch, err)
+	assert.ErrorIs(t, err, ErrWrittenLengthDoesNotMatch)
 }
 
 func TestWriteReadShouldTimeoutIfNoEndingZeroWasReadFromStream(t *testing.T) {
@@ -204,7 +260,7 @@ func TestWriteReadShouldTimeoutIfNoEndingZeroWasReadFromStream(t *testing.T) {
 	// THEN
 	readWriterMock.AssertNumberOfCalls(t, "Write", expectedRetryCount)
 	assert.NotNil(t, err)
-	assert.Equal(t, ErrTimeout, err)
+	assert.ErrorIs(t, err, ErrTimeout)
 }
 
 func TestWriteReadShouldSucceedAndDropMessageAfterFirstZeroSign(t *testing.T) {
@@ -242,20 +298,43 @@ func TestWriteReadShouldSucceedAndDropMessageAfterFirstZeroSign(t *testing.T) {
 	assert.Equal(t, expectedResponse, response)
 }
 
-type ReadWriterBenchmarkMock struct {
-	data []byte
-}
+func TestWriteReadShouldWrapDecodingErrors(t *testing.T) {
+	// GIVEN
+	message := []byte("hello")
+	encoder := NewProtocolParser()
+	encoder.Encode(message)
+	encodedMsg := encoder.Copy()
+	encodedMsg = append(encodedMsg, byte(0))
 
-func (rw *ReadWriterBenchmarkMock) Write(src []byte) (int, error) {
-	return len(src), nil
-}
+	encoder.Encode([]byte("world"))
+	encodedResp := encoder.Copy()
+	encodedResp[len(encodedResp)-1]++
+	encodedResp = append(encodedResp, byte(0))
 
-func (rw *ReadWriterBenchmarkMock) Read(src []byte) (int, error) {
-	copy(src, rw.data)
-	return len(rw.data), io.EOF
+	readWriterMock := &ReadWriterMock{}
+	readWriterMock.On("Write", encodedMsg).Return(len(encodedMsg), nil)
+	readWriterMock.On("Read", mock.Anything).Run(func(args mock.Arguments) {
+		bytes := args[0].([]byte)
+		copy(bytes, encodedResp)
+	}).Return(len(encodedResp), io.EOF)
+
+	readWriter := NewProtocolReadWriter(
+		NewProtocolParser(),
+		1,
+		0,
+		0,
+		1*time.Second)
+
+	// WHEN
+	_, err := readWriter.RetryWriteRead(readWriterMock, encodedMsg)
+
+	// THEN
+	var checksumErr *ChecksumError
+	assert.True(t, errors.Is(err, ErrChecksumMismatch))
+	assert.True(t, errors.As(err, &checksumErr))
 }
 
-func BenchmarkWriteReadShouldSucceed(b *testing.B) {
+func TestWriteReadContextShouldStopReadingWhenContextIsDone(t *testing.T) {
 	// GIVEN
 	message := []byte("hello")
 	encoder := NewProtocolParser()
@@ -263,23 +342,1068 @@ func BenchmarkWriteReadShouldSucceed(b *testing.B) {
 	encodedMsg := encoder.Copy()
 	encodedMsg = append(encodedMsg, byte(0))
 
-	expectedResponse := []byte("world")
-	encoder.Encode(expectedResponse)
-	encodedResp := encoder.Copy()
-	encodedResp = append(encodedResp, byte(0))
+	response := []byte{2}
 
-	readWriterMock := &ReadWriterBenchmarkMock{encodedResp}
+	readWriterMock := &ReadWriterMock{}
+	readWriterMock.On("Write", encodedMsg).Return(len(encodedMsg), nil)
+	readWriterMock.On("Read", mock.Anything).Run(func(args mock.Arguments) {
+		bytes := args[0].([]byte)
+		copy(bytes, response)
+	}).Return(len(response), io.EOF)
 
 	readWriter := NewProtocolReadWriter(
 		NewProtocolParser(),
 		3,
-		0*time.Millisecond,
-		0*time.Millisecond,
+		0,
+		0,
+		10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// WHEN
+	start := time.Now()
+	_, err := readWriter.RetryWriteReadContext(ctx, readWriterMock, encodedMsg)
+
+	// THEN
+	assert.True(t, time.Since(start) < 10*time.Second, "read loop was not interrupted")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	readWriterMock.AssertNumberOfCalls(t, "Write", 1)
+}
+
+func TestWriteReadShouldNotRetryFatalErrors(t *testing.T) {
+	// GIVEN
+	message := []byte("hello")
+	encoder := NewProtocolParser()
+	encoder.Encode(message)
+	encodedMsg := encoder.Copy()
+	encodedMsg = append(encodedMsg, 0)
+
+	readWriterMock := &ReadWriterMock{}
+	readWriterMock.On("Write", encodedMsg).Return(0, io.ErrClosedPipe)
+
+	readWriter := NewProtocolReadWriterWithPolicy(
+		NewProtocolParser(),
+		NewExponentialBackoff(5, time.Millisecond, 10*time.Millisecond),
+		0,
+		10*time.Second)
+
+	// WHEN
+	_, err := readWriter.RetryWriteRead(readWriterMock, encodedMsg)
+
+	// THEN
+	readWriterMock.AssertNumberOfCalls(t, "Write", 1)
+	assert.ErrorIs(t, err, io.ErrClosedPipe)
+}
+
+type SlowReadWriterMock struct {
+	chunks [][]byte
+	delay  time.Duration
+}
+
+func (rw *SlowReadWriterMock) Write(src []byte) (int, error) {
+	return len(src), nil
+}
+
+func (rw *SlowReadWriterMock) Read(src []byte) (int, error) {
+	if len(rw.chunks) == 0 {
+		return 0, io.EOF
+	}
+	time.Sleep(rw.delay)
+	readLen := copy(src, rw.chunks[0])
+	rw.chunks = rw.chunks[1:]
+	return readLen, io.EOF
+}
+
+func TestWriteReadShouldSucceedIfBytesArriveWithinInterByteTimeout(t *testing.T) {
+	// GIVEN
+	encodedMsg := encodeFrames([]byte("hello"))
+	expectedResponse := []byte("world")
+	encodedResp := encodeFrames(expectedResponse)
+	readWriterMock := &SlowReadWriterMock{
+		chunks: [][]byte{encodedResp[:2], encodedResp[2:4], encodedResp[4:]},
+		delay:  1 * time.Millisecond,
+	}
+
+	readWriter := NewProtocolReadWriter(
+		NewProtocolParser(),
+		1,
+		0,
+		100*time.Millisecond,
 		1*time.Second)
 
 	// WHEN
-	for i := 0; i < b.N; i++ {
-		_, err := readWriter.RetryWriteRead(readWriterMock, encodedMsg)
+	response, err := readWriter.RetryWriteRead(readWriterMock, encodedMsg)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, expectedResponse, response)
+}
+
+func TestWriteReadShouldFailIfGapBetweenBytesExceedsInterByteTimeout(t *testing.T) {
+	// GIVEN
+	encodedMsg := encodeFrames([]byte("hello"))
+	encodedResp := encodeFrames([]byte("world"))
+	readWriterMock := &SlowReadWriterMock{
+		chunks: [][]byte{encodedResp[:2], encodedResp[2:]},
+		delay:  30 * time.Millisecond,
+	}
+
+	readWriter := NewProtocolReadWriter(
+		NewProtocolParser(),
+		1,
+		0,
+		10*time.Millisecond,
+		1*time.Second)
+
+	// WHEN
+	_, err := readWriter.RetryWriteRead(readWriterMock, encodedMsg)
+
+	// THEN
+	assert.ErrorIs(t, err, ErrInterByteTimeout)
+}
+
+func TestWriteReadShouldFailIfLineGoesQuietInTheMiddleOfFrame(t *testing.T) {
+	// GIVEN
+	encodedMsg := encodeFrames([]byte("hello"))
+	encodedResp := encodeFrames([]byte("world"))
+	readWriterMock := &SlowReadWriterMock{
+		chunks: [][]byte{encodedResp[:2]},
+	}
+
+	readWriter := NewProtocolReadWriter(
+		NewProtocolParser(),
+		1,
+		0,
+		10*time.Millisecond,
+		10*time.Second)
+
+	// WHEN
+	start := time.Now()
+	_, err := readWriter.RetryWriteRead(readWriterMock, encodedMsg)
+
+	// THEN
+	assert.ErrorIs(t, err, ErrInterByteTimeout)
+	assert.True(t, time.Since(start) < 10*time.Second, "frame was not ended by inter-byte timeout")
+}
+
+type BlockingReadWriterMock struct {
+	io.Reader
+}
+
+func (rw *BlockingReadWriterMock) Write(src []byte) (int, error) {
+	return len(src), nil
+}
+
+func TestWriteReadShouldWaitForBlockingRead(t *testing.T) {
+	// GIVEN
+	encodedMsg := encodeFrames([]byte("hello"))
+	expectedResponse := []byte("world")
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		pipeWriter.Write(encodeFrames(expectedResponse))
+	}()
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second)
+	readWriter.SetBlockingReads(true)
+	defer readWriter.Close()
+
+	// WHEN
+	response, err := readWriter.RetryWriteRead(&BlockingReadWriterMock{pipeReader}, encodedMsg)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, expectedResponse, response)
+}
+
+func TestWriteReadShouldInterruptBlockingReadOnTimeout(t *testing.T) {
+	// GIVEN
+	encodedMsg := encodeFrames([]byte("hello"))
+	pipeReader, _ := io.Pipe()
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 2, 0, 0, 20*time.Millisecond)
+	readWriter.SetBlockingReads(true)
+	defer readWriter.Close()
+
+	// WHEN
+	start := time.Now()
+	_, err := readWriter.RetryWriteRead(&BlockingReadWriterMock{pipeReader}, encodedMsg)
+
+	// THEN
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.True(t, time.Since(start) < 1*time.Second, "blocking read was not interrupted")
+}
+
+func TestWriteReadShouldUseReadDeadlineIfTransportSupportsIt(t *testing.T) {
+	// GIVEN
+	encodedMsg := encodeFrames([]byte("hello"))
+	expectedResponse := []byte("world")
+	client, device := net.Pipe()
+	defer client.Close()
+	defer device.Close()
+	go func() {
+		request := make([]byte, len(encodedMsg))
+		io.ReadFull(device, request)
+		device.Write(encodeFrames(expectedResponse))
+	}()
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second)
+
+	// WHEN
+	response, err := readWriter.RetryWriteRead(client, encodedMsg)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, expectedResponse, response)
+	assert.Nil(t, readWriter.transport.worker, "helper goroutine was started for transport with deadline support")
+}
+
+func TestWriteReadShouldTimeoutUsingReadDeadline(t *testing.T) {
+	// GIVEN
+	encodedMsg := encodeFrames([]byte("hello"))
+	client, device := net.Pipe()
+	defer client.Close()
+	defer device.Close()
+	go io.Copy(io.Discard, device)
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 20*time.Millisecond)
+
+	// WHEN
+	start := time.Now()
+	_, err := readWriter.RetryWriteRead(client, encodedMsg)
+
+	// THEN
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.True(t, time.Since(start) < 1*time.Second, "read deadline was not applied")
+}
+
+func TestWriteReadContextShouldInterruptReadWithDeadline(t *testing.T) {
+	// GIVEN
+	encodedMsg := encodeFrames([]byte("hello"))
+	client, device := net.Pipe()
+	defer client.Close()
+	defer device.Close()
+	go io.Copy(io.Discard, device)
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	// WHEN
+	start := time.Now()
+	_, err := readWriter.RetryWriteReadContext(ctx, client, encodedMsg)
+
+	// THEN
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.True(t, time.Since(start) < 10*time.Second, "read was not interrupted by context")
+}
+
+type FileReadWriterMock struct {
+	*os.File
+}
+
+func (rw *FileReadWriterMock) Write(src []byte) (int, error) {
+	return len(src), nil
+}
+
+func TestWriteReadShouldReadFileWithoutDeadlineSupport(t *testing.T) {
+	// GIVEN
+	encodedMsg := encodeFrames([]byte("hello"))
+	expectedResponse := []byte("world")
+	path := filepath.Join(t.TempDir(), "response")
+	os.WriteFile(path, encodeFrames(expectedResponse), 0o600)
+	file, _ := os.Open(path)
+	defer file.Close()
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second)
+
+	// WHEN
+	response, err := readWriter.RetryWriteRead(&FileReadWriterMock{file}, encodedMsg)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, expectedResponse, response)
+}
+
+func TestWriteReadShouldReadNonBlockingTransportDirectly(t *testing.T) {
+	// GIVEN
+	encodedMsg := encodeFrames([]byte("hello"))
+	readWriterMock := &DeviceReadWriterMock{responses: [][]byte{encodeFrames([]byte("world"))}}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second)
+
+	// WHEN
+	response, err := readWriter.RetryWriteRead(readWriterMock, encodedMsg)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("world"), response)
+	assert.Nil(t, readWriter.transport.worker, "helper goroutine was started without blocking reads")
+}
+
+func TestWriteReadShouldStopIdleHelperGoroutine(t *testing.T) {
+	// GIVEN
+	encodedMsg := encodeFrames([]byte("hello"))
+	readWriterMock := &DeviceReadWriterMock{responses: [][]byte{encodeFrames([]byte("world"))}}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second)
+	readWriter.SetBlockingReads(true)
+	goroutines := runtime.NumGoroutine()
+
+	// WHEN
+	_, err := readWriter.RetryWriteRead(readWriterMock, encodedMsg)
+
+	// THEN
+	// assert.Eventually runs the condition on its own goroutine, so the count is polled directly
+	for i := 0; i < 100 && runtime.NumGoroutine() > goroutines; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Nil(t, err)
+	assert.LessOrEqual(t, runtime.NumGoroutine(), goroutines)
+}
+
+func TestReadFrameShouldNotReturnPendingReadOfOtherReader(t *testing.T) {
+	// GIVEN
+	firstReader, firstWriter := io.Pipe()
+	defer firstWriter.Close()
+	secondReader := &BlockingReadWriterMock{bytes.NewReader(encodeFrames([]byte("second")))}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second)
+	readWriter.SetBlockingReads(true)
+	_, firstErr := readWriter.ReadFrame(&BlockingReadWriterMock{firstReader}, 10*time.Millisecond)
+
+	// WHEN
+	go firstWriter.Write(encodeFrames([]byte("first")))
+	frame, err := readWriter.ReadFrame(secondReader, 1*time.Second)
+
+	// THEN
+	assert.ErrorIs(t, firstErr, ErrTimeout)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("second"), frame)
+}
+
+type readerFunc func(dst []byte) (int, error)
+
+func (f readerFunc) Read(dst []byte) (int, error) {
+	return f(dst)
+}
+
+func TestReadFrameShouldReturnPendingReadOfNotComparableReader(t *testing.T) {
+	// GIVEN
+	pipeReader, pipeWriter := io.Pipe()
+	defer pipeWriter.Close()
+	reader := readerFunc(pipeReader.Read)
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second)
+	readWriter.SetBlockingReads(true)
+	_, firstErr := readWriter.ReadFrame(reader, 10*time.Millisecond)
+
+	// WHEN
+	go pipeWriter.Write(encodeFrames([]byte("late")))
+	frame, err := readWriter.ReadFrame(reader, 1*time.Second)
+
+	// THEN
+	assert.ErrorIs(t, firstErr, ErrTimeout)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("late"), frame)
+}
+
+type sliceReader []byte
+
+func (r sliceReader) Read(dst []byte) (int, error) {
+	return copy(dst, r), io.EOF
+}
+
+func TestSameReaderShouldCompareNotComparableReaders(t *testing.T) {
+	// GIVEN
+	first := sliceReader("first")
+	second := sliceReader("second")
+	function := readerFunc(first.Read)
+	type structReader struct {
+		sliceReader
+	}
+
+	// WHEN
+	// THEN
+	assert.True(t, sameReader(first, first))
+	assert.False(t, sameReader(first, second))
+	assert.True(t, sameReader(function, function))
+	assert.True(t, sameReader(structReader{first}, structReader{first}))
+	assert.False(t, sameReader(first, function))
+}
+
+func TestWriteReadShouldKeepFrameReceivedAfterResponse(t *testing.T) {
+	// GIVEN
+	encodedMsg := encodeFrames([]byte("hello"))
+	response := []byte("world")
+	notification := []byte("event")
+	readWriterMock := &SlowReadWriterMock{
+		chunks: [][]byte{encodeFrames(response, notification)},
+	}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second)
+
+	// WHEN
+	firstResponse, firstErr := readWriter.RetryWriteRead(readWriterMock, encodedMsg)
+	firstResponse = append([]byte{}, firstResponse...)
+	pending := readWriter.Pending()
+	pendingFrame, pendingErr := readWriter.ReadPending()
+	_, noPendingErr := readWriter.ReadPending()
+
+	// THEN
+	assert.Nil(t, firstErr)
+	assert.Equal(t, response, firstResponse)
+	assert.Equal(t, len(encodeFrames(notification)), pending)
+	assert.Nil(t, pendingErr)
+	assert.Equal(t, notification, pendingFrame)
+	assert.Equal(t, ErrNoPendingFrame, noPendingErr)
+	assert.Equal(t, 0, readWriter.Pending())
+}
+
+func TestWriteReadShouldUseBytesLeftFromPreviousTransaction(t *testing.T) {
+	// GIVEN
+	encodedMsg := encodeFrames([]byte("hello"))
+	firstResponse := []byte("first")
+	secondResponse := []byte("second")
+	secondFrame := encodeFrames(secondResponse)
+	readWriterMock := &SlowReadWriterMock{
+		chunks: [][]byte{append(encodeFrames(firstResponse), secondFrame[:3]...), secondFrame[3:]},
+	}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second)
+
+	// WHEN
+	first, firstErr := readWriter.RetryWriteRead(readWriterMock, encodedMsg)
+	first = append([]byte{}, first...)
+	second, secondErr := readWriter.RetryWriteRead(readWriterMock, encodedMsg)
+
+	// THEN
+	assert.Nil(t, firstErr)
+	assert.Nil(t, secondErr)
+	assert.Equal(t, firstResponse, first)
+	assert.Equal(t, secondResponse, second)
+}
+
+func TestWriteReadShouldDropIncompleteFrameLeftAfterInterByteTimeout(t *testing.T) {
+	// GIVEN
+	encodedMsg := encodeFrames([]byte("hello"))
+	firstResponse := []byte("first")
+	secondResponse := []byte("second")
+	partialFrame := encodeFrames([]byte("cut"))[:3]
+	readWriterMock := &DeviceReadWriterMock{
+		responses: [][]byte{append(encodeFrames(firstResponse), partialFrame...), encodeFrames(secondResponse)},
+	}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 10*time.Millisecond, 1*time.Second)
+
+	// WHEN
+	first, firstErr := readWriter.RetryWriteRead(readWriterMock, encodedMsg)
+	first = append([]byte{}, first...)
+	time.Sleep(20 * time.Millisecond)
+	second, secondErr := readWriter.RetryWriteRead(readWriterMock, encodedMsg)
+
+	// THEN
+	assert.Nil(t, firstErr)
+	assert.Nil(t, secondErr)
+	assert.Equal(t, firstResponse, first)
+	assert.Equal(t, secondResponse, second)
+}
+
+func TestWriteReadWithResyncShouldSkipCorruptedFrame(t *testing.T) {
+	// GIVEN
+	encodedMsg := encodeFrames([]byte("hello"))
+	expectedResponse := []byte("world")
+	garbage := []byte{0, 0, 7, 3, 9, 0}
+	corrupted := encodeFrames([]byte("noise"))
+	corrupted[1]++
+	stream := append(append(garbage, corrupted...), encodeFrames(expectedResponse)...)
+	readWriterMock := &SlowReadWriterMock{chunks: [][]byte{stream[:4], stream[4:]}}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second)
+	readWriter.SetResync(true)
+
+	// WHEN
+	response, err := readWriter.RetryWriteRead(readWriterMock, encodedMsg)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, expectedResponse, response)
+	assert.Equal(t, uint64(len(garbage)+len(corrupted)), readWriter.DiscardedBytes())
+}
+
+func TestWriteReadWithResyncShouldCountIncompleteFrameAsDiscarded(t *testing.T) {
+	// GIVEN
+	encodedMsg := encodeFrames([]byte("hello"))
+	partialFrame := encodeFrames([]byte("world"))[:4]
+	readWriterMock := &SlowReadWriterMock{chunks: [][]byte{partialFrame}}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 10*time.Millisecond, 1*time.Second)
+	readWriter.SetResync(true)
+
+	// WHEN
+	_, err := readWriter.RetryWriteRead(readWriterMock, encodedMsg)
+
+	// THEN
+	assert.ErrorIs(t, err, ErrInterByteTimeout)
+	assert.Equal(t, uint64(len(partialFrame)), readWriter.DiscardedBytes())
+	assert.Equal(t, 0, readWriter.Pending())
+}
+
+func TestWriteReadWithoutResyncShouldFailOnCorruptedFrame(t *testing.T) {
+	// GIVEN
+	encodedMsg := encodeFrames([]byte("hello"))
+	corrupted := encodeFrames([]byte("noise"))
+	corrupted[1]++
+	stream := append(corrupted, encodeFrames([]byte("world"))...)
+	readWriterMock := &SlowReadWriterMock{chunks: [][]byte{stream}}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second)
+
+	// WHEN
+	_, err := readWriter.RetryWriteRead(readWriterMock, encodedMsg)
+
+	// THEN
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+	assert.Equal(t, uint64(0), readWriter.DiscardedBytes())
+}
+
+func TestWriteReadWithResyncShouldReportDroppedFrameIfNoValidFrameArrives(t *testing.T) {
+	// GIVEN
+	encodedMsg := encodeFrames([]byte("hello"))
+	corrupted := encodeFrames([]byte("noise"))
+	corrupted[1]++
+	readWriterMock := &SlowReadWriterMock{chunks: [][]byte{corrupted}}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 20*time.Millisecond)
+	readWriter.SetResync(true)
+
+	// WHEN
+	_, err := readWriter.RetryWriteRead(readWriterMock, encodedMsg)
+
+	// THEN
+	assert.ErrorIs(t, err, ErrNoDataRead)
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+}
+
+func TestTransactShouldEncodeRequestAndDecodeResponse(t *testing.T) {
+	// GIVEN
+	request := []byte("hello")
+	expectedResponse := []byte("world")
+	encodedResp := encodeFrames(expectedResponse)
+
+	readWriterMock := &ReadWriterMock{}
+	readWriterMock.On("Write", encodeFrames(request)).Return(len(encodeFrames(request)), nil)
+	readWriterMock.On("Read", mock.Anything).Run(func(args mock.Arguments) {
+		bytes := args[0].([]byte)
+		copy(bytes, encodedResp)
+	}).Return(len(encodedResp), io.EOF)
+
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 3, 0, 0, 1*time.Second)
+
+	// WHEN
+	response, err := readWriter.Transact(readWriterMock, request)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, expectedResponse, response)
+	readWriterMock.AssertNumberOfCalls(t, "Write", 1)
+}
+
+func TestTransactShouldWriteSameRequestOnEachRetry(t *testing.T) {
+	// GIVEN
+	request := []byte("hello")
+	corruptedResp := encodeFrames([]byte("world"))
+	corruptedResp[1]++
+
+	readWriterMock := &ReadWriterMock{}
+	readWriterMock.On("Write", encodeFrames(request)).Return(len(encodeFrames(request)), nil)
+	readWriterMock.On("Read", mock.Anything).Run(func(args mock.Arguments) {
+		bytes := args[0].([]byte)
+		copy(bytes, corruptedResp)
+	}).Return(len(corruptedResp), io.EOF)
+
+	expectedRetryCount := 3
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), expectedRetryCount, 0, 0, 1*time.Second)
+
+	// WHEN
+	_, err := readWriter.Transact(readWriterMock, request)
+
+	// THEN
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+	readWriterMock.AssertNumberOfCalls(t, "Write", expectedRetryCount)
+}
+
+type DeviceReadWriterMock struct {
+	input     []byte
+	responses [][]byte
+	drains    int
+}
+
+func (rw *DeviceReadWriterMock) Write(src []byte) (int, error) {
+	if len(rw.responses) > 0 {
+		rw.input = append(rw.input, rw.responses[0]...)
+		rw.responses = rw.responses[1:]
+	}
+	return len(src), nil
+}
+
+func (rw *DeviceReadWriterMock) Read(src []byte) (int, error) {
+	readLen := copy(src, rw.input)
+	rw.input = rw.input[readLen:]
+	return readLen, io.EOF
+}
+
+type DrainingReadWriterMock struct {
+	DeviceReadWriterMock
+}
+
+func (rw *DrainingReadWriterMock) Drain() error {
+	rw.drains++
+	rw.input = nil
+	return nil
+}
+
+func TestWriteReadWithFlushShouldDiscardStaleInput(t *testing.T) {
+	// GIVEN
+	encodedMsg := encodeFrames([]byte("hello"))
+	staleFrame := encodeFrames([]byte("stale"))
+	expectedResponse := []byte("world")
+	readWriterMock := &DeviceReadWriterMock{
+		input:     staleFrame,
+		responses: [][]byte{encodeFrames(expectedResponse)},
+	}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second)
+	var flushed []byte
+	readWriter.SetFlush(5*time.Millisecond, func(data []byte) {
+		flushed = append(flushed, data...)
+	})
+
+	// WHEN
+	response, err := readWriter.RetryWriteRead(readWriterMock, encodedMsg)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, expectedResponse, response)
+	assert.Equal(t, staleFrame, flushed)
+}
+
+type LateInputReadWriterMock struct {
+	DeviceReadWriterMock
+	late   []byte
+	lateAt time.Time
+}
+
+func (rw *LateInputReadWriterMock) Read(dst []byte) (int, error) {
+	if rw.late != nil && !time.Now().Before(rw.lateAt) {
+		rw.input = append(rw.input, rw.late...)
+		rw.late = nil
+	}
+	return rw.DeviceReadWriterMock.Read(dst)
+}
+
+func TestWriteReadWithFlushShouldWaitForLateStaleInput(t *testing.T) {
+	// GIVEN
+	encodedMsg := encodeFrames([]byte("hello"))
+	staleFrame := encodeFrames([]byte("stale"))
+	expectedResponse := []byte("world")
+	readWriterMock := &LateInputReadWriterMock{
+		DeviceReadWriterMock: DeviceReadWriterMock{responses: [][]byte{encodeFrames(expectedResponse)}},
+		late:                 staleFrame,
+		lateAt:               time.Now().Add(3 * time.Millisecond),
+	}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second)
+	var flushed []byte
+	readWriter.SetFlush(20*time.Millisecond, func(data []byte) {
+		flushed = append(flushed, data...)
+	})
+
+	// WHEN
+	start := time.Now()
+	response, err := readWriter.RetryWriteRead(readWriterMock, encodedMsg)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, expectedResponse, response)
+	assert.Equal(t, staleFrame, flushed)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+}
+
+func TestWriteReadWithoutFlushShouldTakeStaleInputAsResponse(t *testing.T) {
+	// GIVEN
+	encodedMsg := encodeFrames([]byte("hello"))
+	staleResponse := []byte("stale")
+	readWriterMock := &DeviceReadWriterMock{
+		input:     encodeFrames(staleResponse),
+		responses: [][]byte{encodeFrames([]byte("world"))},
+	}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second)
+
+	// WHEN
+	response, err := readWriter.RetryWriteRead(readWriterMock, encodedMsg)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, staleResponse, response)
+}
+
+func TestWriteReadWithFlushShouldUseTransportDrain(t *testing.T) {
+	// GIVEN
+	encodedMsg := encodeFrames([]byte("hello"))
+	expectedResponse := []byte("world")
+	readWriterMock := &DrainingReadWriterMock{DeviceReadWriterMock{
+		input:     encodeFrames([]byte("stale")),
+		responses: [][]byte{encodeFrames(expectedResponse)},
+	}}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second)
+	hookCalls := 0
+	readWriter.SetFlush(time.Second, func(data []byte) {
+		hookCalls++
+	})
+
+	// WHEN
+	start := time.Now()
+	response, err := readWriter.RetryWriteRead(readWriterMock, encodedMsg)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, expectedResponse, response)
+	assert.Equal(t, 1, readWriterMock.drains)
+	assert.Equal(t, 0, hookCalls)
+	assert.True(t, time.Since(start) < time.Second, "quiet period was awaited for transport with Drain method")
+}
+
+func TestTransactWithSequenceShouldIgnoreStaleResponse(t *testing.T) {
+	// GIVEN
+	expectedResponse := []byte("world")
+	staleFrame := encodeFrames(append([]byte{255}, []byte("stale")...))
+	responseFrame := encodeFrames(append([]byte{0}, expectedResponse...))
+	readWriterMock := &DeviceReadWriterMock{
+		responses: [][]byte{append(staleFrame, responseFrame...)},
+	}
+	readWriter := NewProtocolReadWriter(NewSequenceParser(NewProtocolParser()), 1, 0, 0, 1*time.Second)
+
+	// WHEN
+	response, err := readWriter.Transact(readWriterMock, []byte("hello"))
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, expectedResponse, response)
+	assert.Equal(t, uint64(1), readWriter.StaleResponses())
+}
+
+func TestTransactWithSequenceShouldWrapParserPool(t *testing.T) {
+	// GIVEN
+	readWriterMock := &DeviceReadWriterMock{
+		responses: [][]byte{encodeFrames(append([]byte{0}, []byte("world")...))},
+	}
+	readWriter := NewProtocolReadWriter(NewSequenceParser(NewParserPool()), 1, 0, 0, 1*time.Second)
+
+	// WHEN
+	response, err := readWriter.Transact(readWriterMock, []byte("hello"))
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("world"), response)
+}
+
+func TestTransactWithSequenceShouldReportStaleResponse(t *testing.T) {
+	// GIVEN
+	readWriterMock := &DeviceReadWriterMock{
+		responses: [][]byte{encodeFrames(append([]byte{7}, []byte("stale")...))},
+	}
+	readWriter := NewProtocolReadWriter(NewSequenceParser(NewProtocolParser()), 1, 0, 0, 1*time.Second)
+
+	// WHEN
+	_, err := readWriter.Transact(readWriterMock, []byte("hello"))
+
+	// THEN
+	assert.ErrorIs(t, err, ErrStaleResponse)
+	assert.Equal(t, uint64(1), readWriter.StaleResponses())
+}
+
+func TestTransactWithDisabledSequenceShouldWorkWithLegacyDevice(t *testing.T) {
+	// GIVEN
+	expectedResponse := []byte("world")
+	readWriterMock := &DeviceReadWriterMock{
+		responses: [][]byte{encodeFrames(expectedResponse)},
+	}
+	parser := NewSequenceParser(NewProtocolParser())
+	parser.SetEnabled(false)
+	readWriter := NewProtocolReadWriter(parser, 1, 0, 0, 1*time.Second)
+
+	// WHEN
+	response, err := readWriter.Transact(readWriterMock, []byte("hello"))
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, expectedResponse, response)
+}
+
+func TestWriteFrameShouldWriteEncodedFrame(t *testing.T) {
+	// GIVEN
+	payload := []byte("time sync")
+	output := &bytes.Buffer{}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 3, 0, 0, 1*time.Second)
+
+	// WHEN
+	err := readWriter.WriteFrame(output, payload)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, encodeFrames(payload), output.Bytes())
+}
+
+func TestWriteFrameShouldFailOnShortWrite(t *testing.T) {
+	// GIVEN
+	payload := []byte("time sync")
+	readWriterMock := &ReadWriterMock{}
+	readWriterMock.On("Write", encodeFrames(payload)).Return(1, nil)
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 3, 0, 0, 1*time.Second)
+
+	// WHEN
+	err := readWriter.WriteFrame(readWriterMock, payload)
+
+	// THEN
+	assert.Equal(t, ErrWrittenLengthDoesNotMatch, err)
+	readWriterMock.AssertNumberOfCalls(t, "Write", 1)
+}
+
+func TestReadFrameShouldWaitForUnsolicitedFrame(t *testing.T) {
+	// GIVEN
+	event := []byte("event")
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		pipeWriter.Write(encodeFrames(append([]byte{42}, event...)))
+	}()
+	readWriter := NewProtocolReadWriter(NewSequenceParser(NewProtocolParser()), 1, 0, 0, 1*time.Second)
+	readWriter.SetBlockingReads(true)
+	defer readWriter.Close()
+
+	// WHEN
+	frame, err := readWriter.ReadFrame(&BlockingReadWriterMock{pipeReader}, 1*time.Second)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, event, frame)
+}
+
+func TestReadFrameShouldTimeoutIfNoFrameArrives(t *testing.T) {
+	// GIVEN
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second)
+
+	// WHEN
+	_, err := readWriter.ReadFrame(&DeviceReadWriterMock{}, 20*time.Millisecond)
+
+	// THEN
+	assert.Equal(t, ErrTimeout, err)
+}
+
+type EchoReadWriterMock struct {
+	DeviceReadWriterMock
+	corruptAt int
+}
+
+func (rw *EchoReadWriterMock) Write(src []byte) (int, error) {
+	echo := append([]byte{}, src...)
+	if rw.corruptAt > 0 {
+		echo[rw.corruptAt]++
+	}
+	rw.input = append(rw.input, echo...)
+	return rw.DeviceReadWriterMock.Write(src)
+}
+
+func TestWriteReadWithEchoCancellationShouldStripEcho(t *testing.T) {
+	// GIVEN
+	expectedResponse := []byte("world")
+	readWriterMock := &EchoReadWriterMock{
+		DeviceReadWriterMock: DeviceReadWriterMock{responses: [][]byte{encodeFrames(expectedResponse)}},
+	}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second)
+	readWriter.SetEchoCancellation(true)
+
+	// WHEN
+	response, err := readWriter.Transact(readWriterMock, []byte("hello"))
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, expectedResponse, response)
+	assert.Equal(t, 0, readWriter.Pending())
+}
+
+func TestWriteReadWithoutEchoCancellationShouldTakeEchoAsResponse(t *testing.T) {
+	// GIVEN
+	readWriterMock := &EchoReadWriterMock{
+		DeviceReadWriterMock: DeviceReadWriterMock{responses: [][]byte{encodeFrames([]byte("world"))}},
+	}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second)
+
+	// WHEN
+	response, err := readWriter.Transact(readWriterMock, []byte("hello"))
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("hello"), response)
+}
+
+func TestWriteReadWithEchoCancellationShouldReportBusCollision(t *testing.T) {
+	// GIVEN
+	readWriterMock := &EchoReadWriterMock{
+		DeviceReadWriterMock: DeviceReadWriterMock{responses: [][]byte{encodeFrames([]byte("world"))}},
+		corruptAt:            2,
+	}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second)
+	readWriter.SetEchoCancellation(true)
+
+	// WHEN
+	_, err := readWriter.Transact(readWriterMock, []byte("hello"))
+
+	// THEN
+	assert.ErrorIs(t, err, ErrBusCollision)
+	assert.Equal(t, 0, readWriter.Pending())
+}
+
+func TestWriteReadWithEchoCancellationShouldTimeoutWithoutEcho(t *testing.T) {
+	// GIVEN
+	readWriterMock := &DeviceReadWriterMock{}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 20*time.Millisecond)
+	readWriter.SetEchoCancellation(true)
+
+	// WHEN
+	_, err := readWriter.Transact(readWriterMock, []byte("hello"))
+
+	// THEN
+	assert.ErrorIs(t, err, ErrTimeout)
+}
+
+// LateEchoReadWriterMock echoes written data after the delay, followed by the incomplete response
+type LateEchoReadWriterMock struct {
+	LateInputReadWriterMock
+	echoDelay time.Duration
+}
+
+func (rw *LateEchoReadWriterMock) Write(src []byte) (int, error) {
+	response := encodeFrames([]byte("world"))
+	rw.late = append(append([]byte{}, src...), response[:3]...)
+	rw.lateAt = time.Now().Add(rw.echoDelay)
+	return len(src), nil
+}
+
+func TestWriteReadWithEchoCancellationShouldShareReadTimeoutWithResponse(t *testing.T) {
+	// GIVEN
+	readWriterMock := &LateEchoReadWriterMock{echoDelay: 40 * time.Millisecond}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 50*time.Millisecond)
+	readWriter.SetEchoCancellation(true)
+
+	// WHEN
+	start := time.Now()
+	_, err := readWriter.Transact(readWriterMock, []byte("hello"))
+
+	// THEN
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.Less(t, time.Since(start), 80*time.Millisecond)
+}
+
+type HalfDuplexReadWriterMock struct {
+	LateInputReadWriterMock
+	responseDelay time.Duration
+}
+
+func (rw *HalfDuplexReadWriterMock) Write(src []byte) (int, error) {
+	rw.input = append(rw.input, src...)
+	rw.late = encodeFrames([]byte("world"))
+	rw.lateAt = time.Now().Add(rw.responseDelay)
+	return len(src), nil
+}
+
+func TestWriteReadWithEchoCancellationShouldWaitForResponseAfterEcho(t *testing.T) {
+	// GIVEN
+	readWriterMock := &HalfDuplexReadWriterMock{responseDelay: 20 * time.Millisecond}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second)
+	readWriter.SetEchoCancellation(true)
+
+	// WHEN
+	response, err := readWriter.Transact(readWriterMock, []byte("hello"))
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("world"), response)
+}
+
+func TestWriteReadWithEchoCancellationShouldTimeoutIfNoResponseFollowsEcho(t *testing.T) {
+	// GIVEN
+	readWriterMock := &HalfDuplexReadWriterMock{responseDelay: time.Hour}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 30*time.Millisecond)
+	readWriter.SetEchoCancellation(true)
+
+	// WHEN
+	_, err := readWriter.Transact(readWriterMock, []byte("hello"))
+
+	// THEN
+	assert.ErrorIs(t, err, ErrTimeout)
+}
+
+func TestWriteFrameWithEchoCancellationShouldConsumeEcho(t *testing.T) {
+	// GIVEN
+	notification := []byte("event")
+	readWriterMock := &EchoReadWriterMock{
+		DeviceReadWriterMock: DeviceReadWriterMock{responses: [][]byte{encodeFrames(notification)}},
+	}
+	readWriter := NewProtocolReadWriter(NewProtocolParser(), 1, 0, 0, 1*time.Second)
+	readWriter.SetEchoCancellation(true)
+
+	// WHEN
+	writeErr := readWriter.WriteFrame(readWriterMock, []byte("broadcast"))
+	frame, readErr := readWriter.ReadFrame(readWriterMock, 1*time.Second)
+
+	// THEN
+	assert.Nil(t, writeErr)
+	assert.Nil(t, readErr)
+	assert.Equal(t, notification, frame)
+}
+
+type ReadWriterBenchmarkMock struct {
+	data []byte
+}
+
+func (rw *ReadWriterBenchmarkMock) Write(src []byte) (int, error) {
+	return len(src), nil
+}
+
+func (rw *ReadWriterBenchmarkMock) Read(src []byte) (int, error) {
+	copy(src, rw.data)
+	return len(rw.data), io.EOF
+}
+
+func BenchmarkWriteReadShouldSucceed(b *testing.B) {
+	// GIVEN
+	message := []byte("hello")
+	encoder := NewProtocolParser()
+	encoder.Encode(message)
+	encodedMsg := encoder.Copy()
+	encodedMsg = append(encodedMsg, byte(0))
+
+	expectedResponse := []byte("world")
+	encoder.Encode(expectedResponse)
+	encodedResp := encoder.Copy()
+	encodedResp = append(encodedResp, byte(0))
+
+	readWriterMock := &ReadWriterBenchmarkMock{encodedResp}
+
+	readWriter := NewProtocolReadWriter(
+		NewProtocolParser(),
+		3,
+		0*time.Millisecond,
+		0*time.Millisecond,
+		1*time.Second)
+
+	// WHEN
+	for i := 0; i < b.N; i++ {
+		_, err := readWriter.RetryWriteRead(readWriterMock, encodedMsg)
+		if err != nil {
+			b.Fail()
+		}
+	}
+}
+
+func BenchmarkTransactShouldSucceed(b *testing.B) {
+	// GIVEN
+	message := []byte("hello")
+	readWriterMock := &ReadWriterBenchmarkMock{encodeFrames([]byte("world"))}
+
+	readWriter := NewProtocolReadWriter(
+		NewProtocolParser(),
+		3,
+		0*time.Millisecond,
+		0*time.Millisecond,
+		1*time.Second)
+
+	// WHEN
+	for i := 0; i < b.N; i++ {
+		_, err := readWriter.Transact(readWriterMock, message)
 		if err != nil {
 			b.Fail()
 		}
@@ -0,0 +1,162 @@
+package binproto
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrUnknownMessageType is returned by Router when there is no handler for the message type and no fallback
+	ErrUnknownMessageType = errors.New("no handler for message type")
+	// ErrInvalidTypeField is returned when the message type field can't be read from the payload
+	ErrInvalidTypeField = errors.New("invalid message type field")
+)
+
+// Message is the decoded payload passed to the Router handlers
+// Payload is the whole decoded payload, while Body is the part after the type field
+// Both slices are valid only until the handler returns
+type Message struct {
+	Type    uint64
+	Payload []byte
+	Body    []byte
+}
+
+// HandlerFunc handles the message of a single type
+type HandlerFunc func(msg Message) error
+
+// Middleware wraps the handler, e.g. to log, measure or authorize the messages
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// TypeExtractor reads the message type from the payload and returns it with the rest of the payload
+type TypeExtractor func(payload []byte) (msgType uint64, body []byte, err error)
+
+// FrameSource is implemented by readers which return decoded frames, like FrameReader
+type FrameSource interface {
+	ReadFrame() ([]byte, error)
+}
+
+// ByteType is the TypeExtractor which uses the first payload byte as the message type
+func ByteType(payload []byte) (uint64, []byte, error) {
+	if len(payload) < 1 {
+		return 0, nil, fmt.Errorf("%w: %w. Payload is empty", ErrInvalidTypeField, ErrMessageTooShort)
+	}
+	return uint64(payload[0]), payload[1:], nil
+}
+
+// VarintType is the TypeExtractor which reads the message type encoded as unsigned varint
+func VarintType(payload []byte) (uint64, []byte, error) {
+	msgType, n := binary.Uvarint(payload)
+	if n == 0 {
+		return 0, nil, fmt.Errorf("%w: %w. Varint is not complete", ErrInvalidTypeField, ErrMessageTooShort)
+	}
+	if n < 0 {
+		return 0, nil, fmt.Errorf("%w. Varint overflows 64 bits", ErrInvalidTypeField)
+	}
+	return msgType, payload[n:], nil
+}
+
+// Router dispatches decoded payloads to the handlers registered for their message type
+// Handlers and middleware must be registered before the messages are dispatched
+// After that Dispatch can be called from many goroutines, if the handlers allow it
+type Router struct {
+	extract    TypeExtractor
+	middleware []Middleware
+
+	handlers map[uint64]HandlerFunc
+	fallback HandlerFunc
+
+	chains        map[uint64]HandlerFunc
+	fallbackChain HandlerFunc
+
+	decodeErrorHandler func(err error)
+}
+
+// NewRouter returns new Router which reads message types with given extractor
+func NewRouter(extract TypeExtractor) *Router {
+	return &Router{
+		extract:  extract,
+		handlers: make(map[uint64]HandlerFunc),
+		chains:   make(map[uint64]HandlerFunc),
+	}
+}
+
+// Handle registers the handler for given message type, replacing the previous one
+func (r *Router) Handle(msgType uint64, handler HandlerFunc) {
+	r.handlers[msgType] = handler
+	r.chains[msgType] = r.chain(handler)
+}
+
+// HandleFallback registers the handler for messages of types without their own handler
+func (r *Router) HandleFallback(handler HandlerFunc) {
+	r.fallback = handler
+	r.fallbackChain = r.chain(handler)
+}
+
+// HandleDecodeError registers the handler called by Serve with each frame which couldn't be decoded
+// Such frames are skipped, so single corrupted frame doesn't stop serving
+func (r *Router) HandleDecodeError(handler func(err error)) {
+	r.decodeErrorHandler = handler
+}
+
+// Use appends the middleware to the chain. First added middleware is the outermost one
+// Middleware applies to all handlers, including the ones registered earlier
+func (r *Router) Use(middleware ...Middleware) {
+	r.middleware = append(r.middleware, middleware...)
+	for msgType, handler := range r.handlers {
+		r.chains[msgType] = r.chain(handler)
+	}
+	if r.fallback != nil {
+		r.fallbackChain = r.chain(r.fallback)
+	}
+}
+
+// Dispatch reads the message type from the payload and calls its handler
+// ErrUnknownMessageType is returned if there is no handler for the type and no fallback
+func (r *Router) Dispatch(payload []byte) error {
+	msgType, body, err := r.extract(payload)
+	if err != nil {
+		return err
+	}
+	handler, ok := r.chains[msgType]
+	if !ok {
+		handler = r.fallbackChain
+	}
+	if handler == nil {
+		return fmt.Errorf("%w: %v", ErrUnknownMessageType, msgType)
+	}
+	return handler(Message{Type: msgType, Payload: payload, Body: body})
+}
+
+// Serve reads frames from the source and dispatches them until the first error, which is returned
+// Frames which couldn't be decoded, like ones with checksum mismatch, are skipped and passed
+// to the decode error handler, if it is registered. Use middleware to handle errors which should not stop serving
+func (r *Router) Serve(source FrameSource) error {
+	for {
+		payload, err := source.ReadFrame()
+		if err != nil {
+			if !isDecodeError(err) {
+				return err
+			}
+			if r.decodeErrorHandler != nil {
+				r.decodeErrorHandler(err)
+			}
+			continue
+		}
+		if err := r.Dispatch(payload); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *Router) chain(handler HandlerFunc) HandlerFunc {
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		handler = r.middleware[i](handler)
+	}
+	return handler
+}
+
+// isDecodeError reports whether the error was caused by the single corrupted frame, not by the transport
+func isDecodeError(err error) bool {
+	return errors.Is(err, ErrChecksumMismatch) || errors.Is(err, ErrFraming) || errors.Is(err, ErrMessageTooShort)
+}
@@ -0,0 +1,155 @@
+package binproto
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouterShouldDispatchByFirstByte(t *testing.T) {
+	// GIVEN
+	router := NewRouter(ByteType)
+	var received Message
+	router.Handle(2, func(msg Message) error {
+		received = msg
+		return nil
+	})
+
+	// WHEN
+	err := router.Dispatch([]byte{2, 10, 20})
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), received.Type)
+	assert.Equal(t, []byte{10, 20}, received.Body)
+	assert.Equal(t, []byte{2, 10, 20}, received.Payload)
+}
+
+func TestRouterShouldDispatchByVarint(t *testing.T) {
+	// GIVEN
+	router := NewRouter(VarintType)
+	var body []byte
+	router.Handle(300, func(msg Message) error {
+		body = msg.Body
+		return nil
+	})
+
+	// WHEN
+	err := router.Dispatch([]byte{0xAC, 0x02, 7})
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{7}, body)
+}
+
+func TestRouterShouldUseFallbackForUnknownType(t *testing.T) {
+	// GIVEN
+	router := NewRouter(ByteType)
+	var fallbackType uint64
+	router.HandleFallback(func(msg Message) error {
+		fallbackType = msg.Type
+		return nil
+	})
+
+	// WHEN
+	err := router.Dispatch([]byte{9})
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(9), fallbackType)
+}
+
+func TestRouterShouldFailOnUnknownTypeWithoutFallback(t *testing.T) {
+	// GIVEN
+	router := NewRouter(ByteType)
+
+	// WHEN
+	err := router.Dispatch([]byte{9})
+
+	// THEN
+	assert.ErrorIs(t, err, ErrUnknownMessageType)
+}
+
+func TestRouterShouldFailOnEmptyPayload(t *testing.T) {
+	// GIVEN
+	router := NewRouter(ByteType)
+
+	// WHEN
+	err := router.Dispatch(nil)
+
+	// THEN
+	assert.ErrorIs(t, err, ErrInvalidTypeField)
+	assert.ErrorIs(t, err, ErrMessageTooShort)
+}
+
+func TestRouterShouldChainMiddlewareInOrder(t *testing.T) {
+	// GIVEN
+	router := NewRouter(ByteType)
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(msg Message) error {
+				calls = append(calls, name)
+				return next(msg)
+			}
+		}
+	}
+	router.Handle(1, func(msg Message) error {
+		calls = append(calls, "handler")
+		return nil
+	})
+	router.Use(trace("log"), trace("metrics"))
+
+	// WHEN
+	err := router.Dispatch([]byte{1})
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"log", "metrics", "handler"}, calls)
+}
+
+func TestRouterShouldServeFramesFromReader(t *testing.T) {
+	// GIVEN
+	stream := encodeFrames([]byte{1, 'a'}, []byte{2, 'b'}, []byte{1, 'c'})
+	router := NewRouter(ByteType)
+	var bodies []string
+	router.HandleFallback(func(msg Message) error {
+		bodies = append(bodies, string(msg.Body))
+		return nil
+	})
+
+	// WHEN
+	err := router.Serve(NewFrameReader(bytes.NewReader(stream), NewProtocolParser()))
+
+	// THEN
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, []string{"a", "b", "c"}, bodies)
+}
+
+func TestRouterServeShouldSkipCorruptedFrame(t *testing.T) {
+	// GIVEN
+	corrupted := encodeFrames([]byte{1, 'a'})
+	corrupted[1]++
+	stream := append(corrupted, encodeFrames([]byte{1, 'b'})...)
+	router := NewRouter(ByteType)
+	var bodies []string
+	router.Handle(1, func(msg Message) error {
+		bodies = append(bodies, string(msg.Body))
+		return nil
+	})
+	var decodeErrs []error
+	router.HandleDecodeError(func(err error) {
+		decodeErrs = append(decodeErrs, err)
+	})
+
+	// WHEN
+	err := router.Serve(NewFrameReader(bytes.NewReader(stream), NewProtocolParser()))
+
+	// THEN
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, []string{"b"}, bodies)
+	assert.Len(t, decodeErrs, 1)
+	assert.ErrorIs(t, decodeErrs[0], ErrChecksumMismatch)
+}
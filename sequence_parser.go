@@ -0,0 +1,89 @@
+package binproto
+
+import "fmt"
+
+const sequenceHeaderLen = 1
+
+// SequenceParser wraps the EncodeDecoder and adds one byte header with the sequence number to each message
+// Sequence number is incremented on each Encode call and read back by Decode,
+// so ProtocolReadWriter can match the response with the request and ignore stale responses
+// Header can be disabled for legacy devices, in such case messages are passed to the wrapped parser unchanged
+type SequenceParser struct {
+	parser  EncodeDecoder
+	enabled bool
+
+	nextSequence    byte
+	encodedSequence byte
+	decodedSequence byte
+	buffer          []byte
+	frameBuffer     []byte
+}
+
+// NewSequenceParser returns new SequenceParser which wraps given parser and has the header enabled
+func NewSequenceParser(parser EncodeDecoder) *SequenceParser {
+	return &SequenceParser{parser: parser, enabled: true}
+}
+
+// SetEnabled enables or disables the sequence header
+// Both sides must agree on it, so it should be enabled only after the device reported header support
+func (s *SequenceParser) SetEnabled(enabled bool) {
+	s.enabled = enabled
+}
+
+// SequenceEnabled reports whether the sequence header is used
+func (s *SequenceParser) SequenceEnabled() bool {
+	return s.enabled
+}
+
+// Encode prepends the next sequence number to the source and encodes it with the wrapped parser
+func (s *SequenceParser) Encode(src []byte) ([]byte, error) {
+	if !s.enabled {
+		return s.parser.Encode(src)
+	}
+	return s.parser.Encode(s.addHeader(src))
+}
+
+// EncodeFrame works like Encode, but appends the 0 sign, so the frame can be written directly to the stream
+func (s *SequenceParser) EncodeFrame(src []byte) ([]byte, error) {
+	if !s.enabled {
+		return encodeFrame(s.parser, src, &s.frameBuffer)
+	}
+	return encodeFrame(s.parser, s.addHeader(src), &s.frameBuffer)
+}
+
+// Decode decodes the source with the wrapped parser and strips the sequence number from the result
+// Sequence number of the decoded message is returned by LastDecodedSequence
+func (s *SequenceParser) Decode(src []byte) ([]byte, error) {
+	decoded, err := s.parser.Decode(src)
+	if err != nil || !s.enabled {
+		return decoded, err
+	}
+	if len(decoded) < sequenceHeaderLen {
+		return nil, fmt.Errorf("%w. Message has no sequence header", ErrMessageTooShort)
+	}
+	s.decodedSequence = decoded[0]
+	return decoded[sequenceHeaderLen:], nil
+}
+
+// LastEncodedSequence returns the sequence number of the last encoded message
+func (s *SequenceParser) LastEncodedSequence() byte {
+	return s.encodedSequence
+}
+
+// LastDecodedSequence returns the sequence number of the last decoded message
+func (s *SequenceParser) LastDecodedSequence() byte {
+	return s.decodedSequence
+}
+
+// Unwrap returns the wrapped parser
+func (s *SequenceParser) Unwrap() EncodeDecoder {
+	return s.parser
+}
+
+func (s *SequenceParser) addHeader(src []byte) []byte {
+	s.encodedSequence = s.nextSequence
+	s.nextSequence++
+	s.buffer = append(s.buffer[:0], s.encodedSequence)
+	s.buffer = append(s.buffer, src...)
+	return s.buffer
+}
@@ -0,0 +1,89 @@
+package binproto
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSequenceParserEncodeDecodePositive(t *testing.T) {
+	//GIVEN
+	src := []byte{1, 1, 1, 0, 0, 1, 5, 12, 44}
+	parser := NewSequenceParser(NewProtocolParser())
+	//WHEN
+	for i := 0; i < 3; i++ {
+		encoded, _ := parser.Encode(src)
+		encodedSave := append([]byte{}, encoded...)
+		decoded, err := parser.Decode(encodedSave)
+		//THEN
+		if err != nil {
+			t.Error("decoding failed: ", err)
+		}
+		if !bytes.Equal(decoded, src) {
+			t.Errorf("Decoded array %v does not equal to the source %v", decoded, src)
+		}
+		if parser.LastEncodedSequence() != byte(i) || parser.LastDecodedSequence() != byte(i) {
+			t.Errorf("Sequence numbers %v/%v are different than expected %v",
+				parser.LastEncodedSequence(), parser.LastDecodedSequence(), i)
+		}
+	}
+}
+
+func TestSequenceParserAddsHeaderBeforePayload(t *testing.T) {
+	//GIVEN
+	src := []byte("hello")
+	parser := NewSequenceParser(NewProtocolParser())
+	parser.Encode(src)
+	//WHEN
+	encoded, _ := parser.Encode(src)
+	decoded, _ := NewProtocolParser().Decode(encoded)
+	//THEN
+	if !bytes.Equal(decoded, append([]byte{1}, src...)) {
+		t.Errorf("Message %v does not start with the sequence number", decoded)
+	}
+}
+
+func TestSequenceParserDisabledIsCompatibleWithProtocolParser(t *testing.T) {
+	//GIVEN
+	src := []byte("hello")
+	parser := NewSequenceParser(NewProtocolParser())
+	parser.SetEnabled(false)
+	expected, _ := NewProtocolParser().Encode(src)
+	//WHEN
+	encoded, _ := parser.Encode(src)
+	//THEN
+	if !bytes.Equal(encoded, expected) {
+		t.Errorf("Encoded array %v does not equal to the legacy one %v", encoded, expected)
+	}
+}
+
+func TestSequenceParserDecodeWithoutHeaderFails(t *testing.T) {
+	//GIVEN
+	encoded, _ := NewProtocolParser().Encode([]byte{})
+	parser := NewSequenceParser(NewProtocolParser())
+	//WHEN
+	_, err := parser.Decode(encoded)
+	//THEN
+	if !errors.Is(err, ErrMessageTooShort) {
+		t.Errorf("decoding message without header returned %v, expected ErrMessageTooShort", err)
+	}
+}
+
+func TestSequenceParserEncodeFrameWithParserPool(t *testing.T) {
+	//GIVEN
+	src := []byte("hello")
+	parser := NewSequenceParser(NewParserPool())
+	//WHEN
+	frame, err := parser.EncodeFrame(src)
+	//THEN
+	if err != nil {
+		t.Fatal("encoding frame failed: ", err)
+	}
+	if frame[len(frame)-1] != 0 {
+		t.Errorf("Frame %v is not ended with 0 sign", frame)
+	}
+	decoded, err := parser.Decode(frame[:len(frame)-1])
+	if err != nil || !bytes.Equal(decoded, src) {
+		t.Errorf("Decoded array %v does not equal to the source %v, error: %v", decoded, src, err)
+	}
+}
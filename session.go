@@ -0,0 +1,277 @@
+package binproto
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+const defaultUnsolicitedBuffer = 16
+
+var (
+	// ErrSessionClosed is returned by Session operations after Close was called
+	ErrSessionClosed = errors.New("session is closed")
+	// ErrNoMatchFunc is returned by NewSession when SessionConfig has no Match function
+	ErrNoMatchFunc = errors.New("session requires Match function to find responses")
+)
+
+// MatchFunc reports whether given frame is the response to the given request payload
+type MatchFunc func(request, response []byte) bool
+
+// SessionConfig configures the response matching and unsolicited frames delivery of the Session
+type SessionConfig struct {
+	// Match finds the pending request for the received frame. Requests are checked in the order they were sent
+	// It is required, frames which don't match any request are unsolicited
+	Match MatchFunc
+	// OnUnsolicited, if set, is called from the reader goroutine with each frame which is not a response
+	// Given slice is valid only until it returns. It must not block, as it stops the reader
+	OnUnsolicited func(frame []byte)
+	// UnsolicitedBuffer is the capacity of the Unsolicited channel, used when OnUnsolicited is nil
+	// Zero means default capacity. Frames which don't fit into the channel are dropped
+	UnsolicitedBuffer int
+}
+
+// Session is a full-duplex connection to the device
+// Background goroutine reads and decodes the incoming frames, which are either responses to the pending
+// requests or unsolicited frames, like telemetry. Send and Request can be called from many goroutines
+// Encoder and decoder must be separate instances, as they are used by different goroutines
+type Session struct {
+	conn    io.ReadWriteCloser
+	encoder Encoder
+	decoder Decoder
+	config  SessionConfig
+
+	writeMu       sync.Mutex
+	requestBuffer []byte
+
+	mu      sync.Mutex
+	pending []*sessionRequest
+	err     error
+
+	unsolicited  chan []byte
+	dropped      atomic.Uint64
+	decodeErrors atomic.Uint64
+
+	closing   atomic.Bool
+	closeOnce sync.Once
+	closeErr  error
+	done      chan struct{}
+}
+
+type sessionRequest struct {
+	payload  []byte
+	response chan []byte
+}
+
+// NewSession returns new Session which owns given connection and starts reading frames from it
+// Connection Read must block until data arrives and Close must unblock pending Read
+// ErrNoMatchFunc is returned if the config has no Match function
+func NewSession(conn io.ReadWriteCloser, encoder Encoder, decoder Decoder, config SessionConfig) (*Session, error) {
+	if config.Match == nil {
+		return nil, ErrNoMatchFunc
+	}
+	session := &Session{
+		conn:    conn,
+		encoder: encoder,
+		decoder: decoder,
+		config:  config,
+		done:    make(chan struct{}),
+	}
+	if config.OnUnsolicited == nil {
+		bufferLen := config.UnsolicitedBuffer
+		if bufferLen <= 0 {
+			bufferLen = defaultUnsolicitedBuffer
+		}
+		session.unsolicited = make(chan []byte, bufferLen)
+	}
+	go session.readLoop()
+	return session, nil
+}
+
+// Send encodes given payload and writes it as a frame, without waiting for any response
+func (s *Session) Send(payload []byte) error {
+	if s.closing.Load() {
+		return ErrSessionClosed
+	}
+	return s.write(payload)
+}
+
+// Request sends given payload and waits for the matching response
+// Frames received in the meantime, which don't match any pending request, are delivered as unsolicited
+// Returned slice is owned by the caller
+func (s *Session) Request(ctx context.Context, payload []byte) ([]byte, error) {
+	request := &sessionRequest{payload: payload, response: make(chan []byte, 1)}
+	// request is registered before it is written, so the fast response can't be missed
+	s.mu.Lock()
+	if s.err != nil {
+		err := s.err
+		s.mu.Unlock()
+		return nil, err
+	}
+	s.pending = append(s.pending, request)
+	s.mu.Unlock()
+
+	if err := s.write(payload); err != nil {
+		s.removeRequest(request)
+		return nil, err
+	}
+
+	select {
+	case response := <-request.response:
+		return response, nil
+	case <-ctx.Done():
+		s.removeRequest(request)
+		// response might be delivered at the same time the context was done
+		select {
+		case response := <-request.response:
+			return response, nil
+		default:
+		}
+		return nil, ctx.Err()
+	case <-s.done:
+		// response might be delivered just before the reader stopped
+		select {
+		case response := <-request.response:
+			return response, nil
+		default:
+		}
+		return nil, s.Err()
+	}
+}
+
+// Unsolicited returns the channel with frames which are not responses to any request
+// Channel is closed when the session stops. It is nil if SessionConfig.OnUnsolicited is used
+func (s *Session) Unsolicited() <-chan []byte {
+	return s.unsolicited
+}
+
+// DroppedFrames returns the number of unsolicited frames dropped because the channel was full
+func (s *Session) DroppedFrames() uint64 {
+	return s.dropped.Load()
+}
+
+// DecodeErrors returns the number of received frames which couldn't be decoded
+func (s *Session) DecodeErrors() uint64 {
+	return s.decodeErrors.Load()
+}
+
+// Done returns the channel which is closed when the reader goroutine stops
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the reason why the session stopped, or nil if it is still running
+// ErrSessionClosed is returned after Close was called
+func (s *Session) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close closes the connection and waits until the reader goroutine stops
+// Pending requests fail with ErrSessionClosed. It is safe to call Close many times
+func (s *Session) Close() error {
+	s.closeOnce.Do(func() {
+		s.closing.Store(true)
+		s.closeErr = s.conn.Close()
+	})
+	<-s.done
+	return s.closeErr
+}
+
+func (s *Session) write(payload []byte) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	frame, err := encodeFrame(s.encoder, payload, &s.requestBuffer)
+	if err != nil {
+		return err
+	}
+	written, err := s.conn.Write(frame)
+	if err != nil {
+		return err
+	}
+	if written != len(frame) {
+		return ErrWrittenLengthDoesNotMatch
+	}
+	return nil
+}
+
+func (s *Session) readLoop() {
+	reader := NewFrameReader(s.conn, passthroughDecoder{})
+	var err error
+	for {
+		var frame []byte
+		frame, err = reader.ReadFrame()
+		if err != nil {
+			break
+		}
+		message, decodeErr := s.decoder.Decode(frame)
+		if decodeErr != nil {
+			s.decodeErrors.Add(1)
+			continue
+		}
+		s.dispatch(message)
+	}
+	s.stop(err)
+}
+
+// dispatch passes the message to the matching pending request, or to the unsolicited frames consumer
+func (s *Session) dispatch(message []byte) {
+	s.mu.Lock()
+	for i, request := range s.pending {
+		if !s.config.Match(request.payload, message) {
+			continue
+		}
+		s.pending = append(s.pending[:i], s.pending[i+1:]...)
+		// response channel is buffered, it is filled under the lock so Request can't miss it when cancelled
+		request.response <- append([]byte{}, message...)
+		s.mu.Unlock()
+		return
+	}
+	s.mu.Unlock()
+
+	if s.config.OnUnsolicited != nil {
+		s.config.OnUnsolicited(message)
+		return
+	}
+	select {
+	case s.unsolicited <- append([]byte{}, message...):
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// stop records the reason why the reader stopped and releases all waiting goroutines
+func (s *Session) stop(err error) {
+	if s.closing.Load() || err == nil {
+		err = ErrSessionClosed
+	}
+	s.mu.Lock()
+	s.err = err
+	s.pending = nil
+	s.mu.Unlock()
+	if s.unsolicited != nil {
+		close(s.unsolicited)
+	}
+	close(s.done)
+}
+
+func (s *Session) removeRequest(request *sessionRequest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, pending := range s.pending {
+		if pending == request {
+			s.pending = append(s.pending[:i], s.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// passthroughDecoder returns frames unchanged, so the Session can tell decoding errors from read errors
+type passthroughDecoder struct{}
+
+func (passthroughDecoder) Decode(src []byte) ([]byte, error) {
+	return src, nil
+}
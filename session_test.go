@@ -0,0 +1,212 @@
+package binproto
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// deviceMock reads request frames from the connection and answers them with the respond function
+func deviceMock(conn net.Conn, respond func(request []byte) [][]byte) {
+	reader := NewFrameReader(conn, NewProtocolParser())
+	writer := NewFrameWriter(conn, NewProtocolParser())
+	for {
+		request, err := reader.ReadFrame()
+		if err != nil {
+			return
+		}
+		for _, response := range respond(request) {
+			if writer.WriteFrame(response) != nil {
+				return
+			}
+		}
+	}
+}
+
+func matchFirstByte(request, response []byte) bool {
+	return len(request) > 0 && len(response) > 0 && request[0] == response[0]
+}
+
+func TestSessionRequestShouldSkipUnsolicitedFrames(t *testing.T) {
+	// GIVEN
+	client, device := net.Pipe()
+	go deviceMock(device, func(request []byte) [][]byte {
+		return [][]byte{[]byte("Ttelemetry"), append([]byte{}, request...)}
+	})
+	session, _ := NewSession(client, NewProtocolParser(), NewProtocolParser(), SessionConfig{Match: matchFirstByte})
+	defer session.Close()
+
+	// WHEN
+	response, err := session.Request(context.Background(), []byte("Rhello"))
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("Rhello"), response)
+	assert.Equal(t, []byte("Ttelemetry"), <-session.Unsolicited())
+}
+
+func TestSessionShouldMatchConcurrentRequests(t *testing.T) {
+	// GIVEN
+	client, device := net.Pipe()
+	var held []byte
+	go deviceMock(device, func(request []byte) [][]byte {
+		// answer requests in reverse order
+		if held == nil {
+			held = append([]byte{}, request...)
+			return nil
+		}
+		return [][]byte{append([]byte{}, request...), held}
+	})
+	session, _ := NewSession(client, NewProtocolParser(), NewProtocolParser(), SessionConfig{Match: matchFirstByte})
+	defer session.Close()
+
+	// WHEN
+	requests := [][]byte{[]byte("aaa"), []byte("bbb")}
+	responses := make([][]byte, len(requests))
+	errs := make([]error, len(requests))
+	var wg sync.WaitGroup
+	for i := range requests {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i], errs[i] = session.Request(context.Background(), requests[i])
+		}(i)
+	}
+	wg.Wait()
+
+	// THEN
+	for i := range requests {
+		assert.Nil(t, errs[i])
+		assert.Equal(t, requests[i], responses[i])
+	}
+}
+
+func TestSessionShouldPassUnsolicitedFramesToCallback(t *testing.T) {
+	// GIVEN
+	client, device := net.Pipe()
+	frames := make(chan []byte, 1)
+	session, _ := NewSession(client, NewProtocolParser(), NewProtocolParser(), SessionConfig{
+		Match:         matchFirstByte,
+		OnUnsolicited: func(frame []byte) { frames <- append([]byte{}, frame...) },
+	})
+	defer session.Close()
+
+	// WHEN
+	go NewFrameWriter(device, NewProtocolParser()).WriteFrame([]byte("event"))
+
+	// THEN
+	assert.Equal(t, []byte("event"), <-frames)
+	assert.Nil(t, session.Unsolicited())
+}
+
+func TestSessionRequestShouldStopWhenContextIsDone(t *testing.T) {
+	// GIVEN
+	client, device := net.Pipe()
+	go deviceMock(device, func(request []byte) [][]byte { return nil })
+	session, _ := NewSession(client, NewProtocolParser(), NewProtocolParser(), SessionConfig{Match: matchFirstByte})
+	defer session.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	// WHEN
+	_, err := session.Request(ctx, []byte("hello"))
+
+	// THEN
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestSessionCloseShouldReleasePendingRequests(t *testing.T) {
+	// GIVEN
+	client, device := net.Pipe()
+	go deviceMock(device, func(request []byte) [][]byte { return nil })
+	session, _ := NewSession(client, NewProtocolParser(), NewProtocolParser(), SessionConfig{Match: matchFirstByte})
+	result := make(chan error)
+	go func() {
+		_, err := session.Request(context.Background(), []byte("hello"))
+		result <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// WHEN
+	closeErr := session.Close()
+
+	// THEN
+	assert.Nil(t, closeErr)
+	assert.ErrorIs(t, <-result, ErrSessionClosed)
+	_, open := <-session.Unsolicited()
+	assert.False(t, open)
+	assert.ErrorIs(t, session.Send([]byte("hello")), ErrSessionClosed)
+}
+
+func TestSessionShouldStopWhenConnectionEnds(t *testing.T) {
+	// GIVEN
+	client, device := net.Pipe()
+	session, _ := NewSession(client, NewProtocolParser(), NewProtocolParser(), SessionConfig{Match: matchFirstByte})
+	defer session.Close()
+
+	// WHEN
+	device.Close()
+	<-session.Done()
+
+	// THEN
+	assert.ErrorIs(t, session.Err(), io.EOF)
+}
+
+func TestSessionShouldCountCorruptedFrames(t *testing.T) {
+	// GIVEN
+	client, device := net.Pipe()
+	session, _ := NewSession(client, NewProtocolParser(), NewProtocolParser(), SessionConfig{Match: matchFirstByte})
+	defer session.Close()
+	corrupted := encodeFrames([]byte("hello"))
+	corrupted[1]++
+
+	// WHEN
+	go device.Write(append(corrupted, encodeFrames([]byte("world"))...))
+	frame := <-session.Unsolicited()
+
+	// THEN
+	assert.Equal(t, []byte("world"), frame)
+	assert.Equal(t, uint64(1), session.DecodeErrors())
+}
+
+func TestNewSessionShouldRequireMatchFunction(t *testing.T) {
+	// GIVEN
+	client, device := net.Pipe()
+	defer client.Close()
+	defer device.Close()
+
+	// WHEN
+	session, err := NewSession(client, NewProtocolParser(), NewProtocolParser(), SessionConfig{})
+
+	// THEN
+	assert.Nil(t, session)
+	assert.Equal(t, ErrNoMatchFunc, err)
+}
+
+func TestSessionRequestShouldReturnResponseDeliveredWhenContextIsDone(t *testing.T) {
+	// GIVEN
+	client, device := net.Pipe()
+	go deviceMock(device, func(request []byte) [][]byte { return [][]byte{append([]byte{}, request...)} })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	session, _ := NewSession(client, NewProtocolParser(), NewProtocolParser(), SessionConfig{
+		Match: func(request, response []byte) bool {
+			// context is done right before the response is delivered
+			cancel()
+			return matchFirstByte(request, response)
+		},
+	})
+	defer session.Close()
+
+	// WHEN
+	response, err := session.Request(ctx, []byte("hello"))
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("hello"), response)
+}
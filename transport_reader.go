@@ -0,0 +1,275 @@
+package binproto
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+const (
+	defaultReadChunkSize = 256
+	// emptyReadPollInterval is the time to wait before the next read, when the transport returned no data
+	emptyReadPollInterval = time.Millisecond
+	// readWorkerIdleTimeout is the time after which idle helper goroutine exits
+	readWorkerIdleTimeout = 100 * time.Millisecond
+)
+
+// errReadDeadline is returned by transportReader when no data was read before the given deadline
+var errReadDeadline = errors.New("read deadline exceeded")
+
+// deadlineReader is implemented by transports which support read deadlines, like net.Conn and os.File
+type deadlineReader interface {
+	io.Reader
+	SetReadDeadline(t time.Time) error
+}
+
+type readRequest struct {
+	reader io.Reader
+	buffer []byte
+}
+
+type readResult struct {
+	readLen int
+	err     error
+}
+
+// transportReader performs reads which can be interrupted by the deadline or context cancellation
+// If the transport supports read deadlines, they are used directly
+// Otherwise the transport is read directly, so it must not block, unless blocking reads are enabled.
+// Blocking reads are done on the helper goroutine, so the caller can stop waiting for them at any time.
+// Read which was abandoned by the caller stays pending and its result is returned by the next read
+// from the same reader. Helper goroutine exits when it is idle, so closing transportReader is optional
+type transportReader struct {
+	chunk    []byte
+	timer    *time.Timer
+	blocking bool
+
+	worker        *readWorker
+	pending       bool
+	pendingReader io.Reader
+	closed        bool
+
+	// probedReader is the last reader checked for the deadline support
+	probedReader      io.Reader
+	deadlineSupported bool
+}
+
+// readWorker runs reads on the helper goroutine, which exits after being idle for readWorkerIdleTimeout
+type readWorker struct {
+	mu       sync.Mutex
+	running  bool
+	requests chan readRequest
+	results  chan readResult
+}
+
+func newTransportReader() *transportReader {
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	return &transportReader{chunk: make([]byte, defaultReadChunkSize), timer: timer}
+}
+
+// read reads next chunk of data from the reader, waiting until given deadline or context is done
+// Zero deadline means no deadline. Returned slice is valid until the next read call
+func (t *transportReader) read(ctx context.Context, reader io.Reader, deadline time.Time) ([]byte, error) {
+	if t.closed {
+		return nil, io.ErrClosedPipe
+	}
+	if t.pending {
+		if sameReader(t.pendingReader, reader) {
+			return t.readAsync(ctx, reader, deadline)
+		}
+		t.abandonPending()
+	}
+	if dlReader, ok := reader.(deadlineReader); ok && t.supportsDeadline(dlReader) {
+		return t.readWithDeadline(ctx, dlReader, deadline)
+	}
+	if !t.blocking {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		readLen, err := reader.Read(t.chunk)
+		return t.chunk[:readLen], err
+	}
+	return t.readAsync(ctx, reader, deadline)
+}
+
+// supportsDeadline checks whether the reader really supports deadlines, e.g. regular os.File doesn't
+// Result is remembered for the last checked reader
+func (t *transportReader) supportsDeadline(reader deadlineReader) bool {
+	if t.probedReader == nil || !sameReader(t.probedReader, reader) {
+		t.probedReader = reader
+		t.deadlineSupported = reader.SetReadDeadline(time.Time{}) == nil
+	}
+	return t.deadlineSupported
+}
+
+func (t *transportReader) readWithDeadline(ctx context.Context, reader deadlineReader, deadline time.Time) ([]byte, error) {
+	if err := reader.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+	defer reader.SetReadDeadline(time.Time{})
+	if ctx.Done() != nil {
+		// interrupt blocked read when the context is done
+		stop := context.AfterFunc(ctx, func() {
+			reader.SetReadDeadline(time.Now())
+		})
+		defer stop()
+	}
+
+	readLen, err := reader.Read(t.chunk)
+	if err != nil && isTimeout(err) {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, errReadDeadline
+	}
+	return t.chunk[:readLen], err
+}
+
+func (t *transportReader) readAsync(ctx context.Context, reader io.Reader, deadline time.Time) ([]byte, error) {
+	if t.worker == nil {
+		t.worker = &readWorker{requests: make(chan readRequest, 1), results: make(chan readResult, 1)}
+	}
+	if !t.pending {
+		t.worker.start(readRequest{reader, t.chunk})
+		t.pending = true
+		t.pendingReader = reader
+	}
+
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		t.timer.Reset(time.Until(deadline))
+		defer stopTimer(t.timer)
+		timeout = t.timer.C
+	}
+
+	select {
+	case result := <-t.worker.results:
+		t.pending = false
+		t.pendingReader = nil
+		return t.chunk[:result.readLen], result.err
+	case <-timeout:
+		return nil, errReadDeadline
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// abandonPending leaves the pending read of other reader to its worker, which drops its result
+// Both the worker and the chunk are replaced, as the abandoned read still uses them
+func (t *transportReader) abandonPending() {
+	t.worker.stop()
+	t.worker = nil
+	t.chunk = make([]byte, defaultReadChunkSize)
+	t.pending = false
+	t.pendingReader = nil
+}
+
+// wait sleeps for given time, unless the context is done earlier
+func (t *transportReader) wait(ctx context.Context, duration time.Duration) error {
+	t.timer.Reset(duration)
+	defer stopTimer(t.timer)
+	select {
+	case <-t.timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close stops the helper goroutine. If it is blocked in read, it will exit as soon as the read returns
+func (t *transportReader) close() {
+	if t.closed {
+		return
+	}
+	t.closed = true
+	if t.worker != nil {
+		t.worker.stop()
+	}
+}
+
+// start queues the read request, starting the helper goroutine if it is not running
+// Only one request may be queued at the same time
+func (w *readWorker) start(request readRequest) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.running {
+		w.running = true
+		go w.loop()
+	}
+	w.requests <- request
+}
+
+// stop closes the requests channel, so the helper goroutine exits after the current read
+func (w *readWorker) stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	close(w.requests)
+}
+
+func (w *readWorker) loop() {
+	idle := time.NewTimer(readWorkerIdleTimeout)
+	defer idle.Stop()
+	for {
+		select {
+		case request, ok := <-w.requests:
+			if !ok {
+				return
+			}
+			readLen, err := request.reader.Read(request.buffer)
+			w.results <- readResult{readLen, err}
+			stopTimer(idle)
+			idle.Reset(readWorkerIdleTimeout)
+		case <-idle.C:
+			// request queued after the timer fired must not be left without the goroutine
+			w.mu.Lock()
+			if len(w.requests) > 0 {
+				w.mu.Unlock()
+				idle.Reset(readWorkerIdleTimeout)
+				continue
+			}
+			w.running = false
+			w.mu.Unlock()
+			return
+		}
+	}
+}
+
+// sameReader compares readers without panicking on not comparable types
+// Slices, maps and functions are compared by their pointers. Other not comparable values, like structs
+// with slice fields, have no identity, so readers of the same type are taken as the same reader
+func sameReader(a, b io.Reader) bool {
+	if a == nil || b == nil || reflect.TypeOf(a) != reflect.TypeOf(b) {
+		return false
+	}
+	if reflect.TypeOf(a).Comparable() {
+		return a == b
+	}
+	valueA, valueB := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch valueA.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Func:
+		return valueA.Pointer() == valueB.Pointer()
+	}
+	return true
+}
+
+func stopTimer(timer *time.Timer) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+}
+
+func isTimeout(err error) bool {
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+	var timeoutErr interface{ Timeout() bool }
+	return errors.As(err, &timeoutErr) && timeoutErr.Timeout()
+}